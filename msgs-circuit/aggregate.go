@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+)
+
+const (
+	aggregateProofFilename         = "agg_proof.bin"
+	aggregatePublicWitnessFilename = "agg_public.wtns"
+	aggregateShapeKeyFilename      = "agg_shape.key"
+	aggregateInputsFilename        = "agg_proofs.txt"
+)
+
+// runAggregate: aggregate gộp mọi proof trong các thư mục con của --proofs
+// (output của prove-tx --backend plonk --curve bls12-377, cùng một shape) thành
+// một proof Groth16 trên txscircuit.AggregateCurve. Public input duy nhất là
+// txscircuit.AggregateHash của các public witness, theo thứ tự tên thư mục (ghi
+// lại trong agg_proofs.txt).
+func runAggregate(args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	proofsDir := fs.String("proofs", "proofs", "directory whose subdirectories were written by prove-tx --backend plonk --curve bls12-377")
+	storeDir := fs.String("store", txscircuit.DefaultStoreDir, "artifact store directory")
+	outDir := fs.String("out", "aggregate", "output directory for the aggregated proof")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go run . aggregate --proofs <dir> [--store <dir>] [--out <dir>]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dirs, err := innerProofDirs(*proofsDir)
+	if err != nil {
+		return err
+	}
+	var (
+		shapeKey        string
+		innerVK         plonk.VerifyingKey
+		proofs          []plonk.Proof
+		publicWitnesses []witness.Witness
+	)
+	innerStore := txscircuit.NewArtifactStoreForCurve(*storeDir, txscircuit.InnerCurve)
+	for _, dir := range dirs {
+		key, err := readInnerProofShape(dir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dir, err)
+		}
+		if innerVK == nil {
+			shapeKey = key
+			if innerVK, err = innerStore.LoadPlonkVerifyingKey(shapeKey); err != nil {
+				return fmt.Errorf("load inner verifying key for shape %s: %w", shapeKey, err)
+			}
+		} else if key != shapeKey {
+			return fmt.Errorf("%s: shape %s differs from %s; all proofs must share one shape", dir, key, shapeKey)
+		}
+
+		proof, err := readProof(txscircuit.BackendPlonk, txscircuit.InnerCurve, filepath.Join(dir, proofFilename))
+		if err != nil {
+			return fmt.Errorf("%s: read proof: %w", dir, err)
+		}
+		publicWitness, err := readWitness(txscircuit.InnerCurve, filepath.Join(dir, publicWitnessFilename))
+		if err != nil {
+			return fmt.Errorf("%s: read public witness: %w", dir, err)
+		}
+		// Một proof con sai làm circuit không solve được; verify trước để
+		// biết proof nào hỏng.
+		if err := verifyProof(txscircuit.BackendPlonk, txscircuit.InnerCurve, proof, innerVK, publicWitness); err != nil {
+			return fmt.Errorf("%s: inner proof invalid: %w", dir, err)
+		}
+		proofs = append(proofs, proof.(plonk.Proof))
+		publicWitnesses = append(publicWitnesses, publicWitness)
+	}
+
+	circuit, err := txscircuit.NewAggregateCircuit(innerVK, len(proofs))
+	if err != nil {
+		return err
+	}
+	assignment, err := txscircuit.NewAggregateAssignment(proofs, publicWitnesses)
+	if err != nil {
+		return err
+	}
+	artifacts, cached, err := txscircuit.NewArtifactStoreForCurve(*storeDir, txscircuit.AggregateCurve).LoadOrSetup(circuit)
+	if err != nil {
+		return err
+	}
+	aggregateKey := circuit.ShapeKey()
+	fmt.Printf("Aggregate %s of %d proofs (curve: %s, cached: %v, constraints: %d)\n",
+		aggregateKey, len(proofs), txscircuit.AggregateCurve, cached, artifacts.CS.GetNbConstraints())
+
+	fullWitness, err := frontend.NewWitness(assignment, txscircuit.AggregateCurve.ScalarField())
+	if err != nil {
+		return fmt.Errorf("full witness: %w", err)
+	}
+	proof, err := groth16.Prove(artifacts.CS, artifacts.PK, fullWitness)
+	if err != nil {
+		return fmt.Errorf("prove: %w", err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return fmt.Errorf("public witness: %w", err)
+	}
+	if err := groth16.Verify(proof, artifacts.VK, publicWitness); err != nil {
+		return fmt.Errorf("verify aggregated proof: %w", err)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return fmt.Errorf("output dir: %w", err)
+	}
+	if err := txscircuit.WriteToFile(filepath.Join(*outDir, aggregateProofFilename), proof); err != nil {
+		return fmt.Errorf("write proof: %w", err)
+	}
+	if err := txscircuit.WriteToFile(filepath.Join(*outDir, aggregatePublicWitnessFilename), publicWitness); err != nil {
+		return fmt.Errorf("write public witness: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, aggregateShapeKeyFilename), []byte(aggregateKey+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write shape key: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, aggregateInputsFilename), []byte(strings.Join(dirs, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write proof list: %w", err)
+	}
+
+	fmt.Printf("Public hash: %#x\n", assignment.PublicHash)
+	fmt.Printf("Aggregated proof written to %s\n", *outDir)
+	return nil
+}
+
+// innerProofDirs trả về các thư mục con của dir có proof, sắp theo tên
+// (os.ReadDir đã sắp sẵn).
+func innerProofDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read proofs dir: %w", err)
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(path, proofFilename)); err == nil {
+			dirs = append(dirs, path)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no prove-tx output (%s) under %s", proofFilename, dir)
+	}
+	return dirs, nil
+}
+
+// readInnerProofShape kiểm tra dir là proof PLONK trên txscircuit.InnerCurve
+// và trả về shape key của nó.
+func readInnerProofShape(dir string) (string, error) {
+	backend, err := readBackend(dir)
+	if err != nil {
+		return "", err
+	}
+	curve, err := readCurve(dir)
+	if err != nil {
+		return "", err
+	}
+	if backend != txscircuit.BackendPlonk || curve != txscircuit.InnerCurve {
+		return "", fmt.Errorf("proof is %s on %s; aggregate needs prove-tx --backend %s --curve %s",
+			backend, curve, txscircuit.BackendPlonk, txscircuit.InnerCurve)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, shapeKeyFilename))
+	if err != nil {
+		return "", fmt.Errorf("read shape key: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", errors.New("empty shape key")
+	}
+	return key, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// proofObject là proof hoặc verifying key của một trong hai backend
+// (groth16.Proof/plonk.Proof, groth16.VerifyingKey/plonk.VerifyingKey).
+type proofObject interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// proveWithStore lấy CS/PK của backend từ store (setup nếu shape mới) rồi
+// prove fullWitness trên curve của store.
+func proveWithStore(store *txscircuit.ArtifactStore, backend txscircuit.Backend, circuit *txscircuit.TxsFieldCircuit, fullWitness witness.Witness) (proofObject, error) {
+	shapeKey := circuit.ShapeKey()
+	switch backend {
+	case txscircuit.BackendPlonk:
+		artifacts, cached, err := store.LoadOrSetupPlonk(circuit)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Shape %s (backend: %s, curve: %s, cached: %v, constraints: %d)\n", shapeKey, backend, store.Curve, cached, artifacts.CS.GetNbConstraints())
+		proof, err := plonk.Prove(artifacts.CS, artifacts.PK, fullWitness, plonkProverOptions(store.Curve)...)
+		if err != nil {
+			return nil, fmt.Errorf("prove: %w", err)
+		}
+		return proof, nil
+	default:
+		artifacts, cached, err := store.LoadOrSetup(circuit)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Shape %s (backend: %s, curve: %s, cached: %v, constraints: %d)\n", shapeKey, backend, store.Curve, cached, artifacts.CS.GetNbConstraints())
+		proof, err := groth16.Prove(artifacts.CS, artifacts.PK, fullWitness)
+		if err != nil {
+			return nil, fmt.Errorf("prove: %w", err)
+		}
+		return proof, nil
+	}
+}
+
+func verifyProof(backend txscircuit.Backend, curve ecc.ID, proof, vk proofObject, publicWitness witness.Witness) error {
+	if backend == txscircuit.BackendPlonk {
+		return plonk.Verify(proof.(plonk.Proof), vk.(plonk.VerifyingKey), publicWitness, plonkVerifierOptions(curve)...)
+	}
+	return groth16.Verify(proof.(groth16.Proof), vk.(groth16.VerifyingKey), publicWitness)
+}
+
+// plonkProverOptions: proof PLONK trên txscircuit.InnerCurve là đầu vào của
+// aggregate nên phải prove với txscircuit.InnerProverOption.
+func plonkProverOptions(curve ecc.ID) []backend.ProverOption {
+	if curve == txscircuit.InnerCurve {
+		return []backend.ProverOption{txscircuit.InnerProverOption()}
+	}
+	return nil
+}
+
+func plonkVerifierOptions(curve ecc.ID) []backend.VerifierOption {
+	if curve == txscircuit.InnerCurve {
+		return []backend.VerifierOption{txscircuit.InnerVerifierOption()}
+	}
+	return nil
+}
+
+// parseCurve đọc tên curve của --curve ("bn254", "bls12-377" hoặc
+// "bls12_377"). prove-tx chỉ hỗ trợ BN254 và txscircuit.InnerCurve.
+func parseCurve(name string) (ecc.ID, error) {
+	curve, err := ecc.IDFromString(strings.ReplaceAll(strings.ToLower(name), "-", "_"))
+	if err != nil || (curve != ecc.BN254 && curve != txscircuit.InnerCurve) {
+		return ecc.UNKNOWN, fmt.Errorf("unsupported curve %q (want %s or %s)", name, ecc.BN254, txscircuit.InnerCurve)
+	}
+	return curve, nil
+}
+
+// readCurve đọc curve mà prove-tx ghi trong dir. Thư mục proof cũ không có
+// file này là BN254.
+func readCurve(dir string) (ecc.ID, error) {
+	data, err := os.ReadFile(filepath.Join(dir, curveFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return ecc.BN254, nil
+	}
+	if err != nil {
+		return ecc.UNKNOWN, fmt.Errorf("read curve: %w", err)
+	}
+	return parseCurve(strings.TrimSpace(string(data)))
+}
+
+// readBackend đọc backend mà prove-tx ghi trong dir. Thư mục proof cũ không có
+// file này là Groth16.
+func readBackend(dir string) (txscircuit.Backend, error) {
+	data, err := os.ReadFile(filepath.Join(dir, backendFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return txscircuit.BackendGroth16, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read backend: %w", err)
+	}
+	return txscircuit.ParseBackend(strings.TrimSpace(string(data)))
+}
+
+func loadVerifyingKey(store *txscircuit.ArtifactStore, backend txscircuit.Backend, shapeKey string) (proofObject, error) {
+	if backend == txscircuit.BackendPlonk {
+		return store.LoadPlonkVerifyingKey(shapeKey)
+	}
+	return store.LoadVerifyingKey(shapeKey)
+}
+
+func readVerifyingKey(backend txscircuit.Backend, curve ecc.ID, path string) (proofObject, error) {
+	var vk proofObject = groth16.NewVerifyingKey(curve)
+	if backend == txscircuit.BackendPlonk {
+		vk = plonk.NewVerifyingKey(curve)
+	}
+	return vk, readFromFile(path, vk)
+}
+
+func readProof(backend txscircuit.Backend, curve ecc.ID, path string) (proofObject, error) {
+	var proof proofObject = groth16.NewProof(curve)
+	if backend == txscircuit.BackendPlonk {
+		proof = plonk.NewProof(curve)
+	}
+	return proof, readFromFile(path, proof)
+}
+
+func readFromFile(path string, rf io.ReaderFrom) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = rf.ReadFrom(file)
+	return err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test/unsafekzg"
+)
+
+// backendReport là số đo của một backend trên cùng một circuit và witness.
+type backendReport struct {
+	Backend     txscircuit.Backend
+	Constraints int
+	Compile     time.Duration
+	Setup       time.Duration
+	Prove       time.Duration // trung bình của --runs lần prove
+	Verify      time.Duration
+	ProofSize   int64
+	PKSize      int64
+	VKSize      int64
+}
+
+// runCompareBackends: compare-backends compile, setup, prove và verify cùng
+// một tx (chọn như prove-tx) bằng cả Groth16 lẫn PLONK, không dùng store, rồi
+// in bảng so sánh.
+func runCompareBackends(args []string) error {
+	fs := flag.NewFlagSet("compare-backends", flag.ContinueOnError)
+	input := addTxInputFlags(fs)
+	runs := fs.Int("runs", 3, "number of proofs per backend; the reported proving time is their mean")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go run . compare-backends (--tx <hex|base64> | --tx-file <path>) --field <msgIndex>:<path> ... [--runs n]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 {
+		return errors.New("--runs must be at least 1")
+	}
+
+	circuit, assignment, err := input.build()
+	if err != nil {
+		return err
+	}
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return fmt.Errorf("full witness: %w", err)
+	}
+	fmt.Printf("Shape %s, tx %d bytes\n", circuit.ShapeKey(), len(circuit.PublicTxBytes))
+
+	var reports []backendReport
+	for _, backend := range []txscircuit.Backend{txscircuit.BackendGroth16, txscircuit.BackendPlonk} {
+		fmt.Printf("Measuring %s...\n", backend)
+		report, err := measureBackend(backend, circuit, fullWitness, *runs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", backend, err)
+		}
+		reports = append(reports, report)
+	}
+	fmt.Println()
+	printBackendReports(os.Stdout, reports)
+	return nil
+}
+
+func measureBackend(backend txscircuit.Backend, circuit *txscircuit.TxsFieldCircuit, fullWitness witness.Witness, runs int) (backendReport, error) {
+	report := backendReport{Backend: backend}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return report, fmt.Errorf("public witness: %w", err)
+	}
+
+	var newBuilder frontend.NewBuilder = r1cs.NewBuilder
+	if backend == txscircuit.BackendPlonk {
+		newBuilder = scs.NewBuilder
+	}
+	start := time.Now()
+	cs, err := frontend.Compile(ecc.BN254.ScalarField(), newBuilder, circuit)
+	if err != nil {
+		return report, fmt.Errorf("compile: %w", err)
+	}
+	report.Compile = time.Since(start)
+	report.Constraints = cs.GetNbConstraints()
+
+	// Setup của PLONK gồm cả sinh SRS (unsafekzg); với SRS từ ceremony thì
+	// bước này chỉ còn đọc file.
+	var (
+		pk, vk io.WriterTo
+		prove  func() (proofObject, error)
+		verify func(proofObject) error
+	)
+	start = time.Now()
+	switch backend {
+	case txscircuit.BackendPlonk:
+		srs, srsLagrange, err := unsafekzg.NewSRS(cs)
+		if err != nil {
+			return report, fmt.Errorf("srs: %w", err)
+		}
+		plonkPK, plonkVK, err := plonk.Setup(cs, srs, srsLagrange)
+		if err != nil {
+			return report, fmt.Errorf("setup: %w", err)
+		}
+		pk, vk = plonkPK, plonkVK
+		prove = func() (proofObject, error) { return plonk.Prove(cs, plonkPK, fullWitness) }
+		verify = func(proof proofObject) error { return plonk.Verify(proof.(plonk.Proof), plonkVK, publicWitness) }
+	default:
+		groth16PK, groth16VK, err := groth16.Setup(cs)
+		if err != nil {
+			return report, fmt.Errorf("setup: %w", err)
+		}
+		pk, vk = groth16PK, groth16VK
+		prove = func() (proofObject, error) { return groth16.Prove(cs, groth16PK, fullWitness) }
+		verify = func(proof proofObject) error { return groth16.Verify(proof.(groth16.Proof), groth16VK, publicWitness) }
+	}
+	report.Setup = time.Since(start)
+
+	var proof proofObject
+	start = time.Now()
+	for range runs {
+		if proof, err = prove(); err != nil {
+			return report, fmt.Errorf("prove: %w", err)
+		}
+	}
+	report.Prove = time.Since(start) / time.Duration(runs)
+
+	start = time.Now()
+	if err := verify(proof); err != nil {
+		return report, fmt.Errorf("verify: %w", err)
+	}
+	report.Verify = time.Since(start)
+
+	if report.ProofSize, err = proof.WriteTo(io.Discard); err != nil {
+		return report, fmt.Errorf("proof size: %w", err)
+	}
+	if report.PKSize, err = pk.WriteTo(io.Discard); err != nil {
+		return report, fmt.Errorf("proving key size: %w", err)
+	}
+	if report.VKSize, err = vk.WriteTo(io.Discard); err != nil {
+		return report, fmt.Errorf("verifying key size: %w", err)
+	}
+	return report, nil
+}
+
+func printBackendReports(w io.Writer, reports []backendReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	row := func(name string, value func(backendReport) string) {
+		fmt.Fprintf(tw, "%s\t", name)
+		for _, report := range reports {
+			fmt.Fprintf(tw, "%s\t", value(report))
+		}
+		fmt.Fprintln(tw)
+	}
+	row("", func(r backendReport) string { return string(r.Backend) })
+	row("constraints", func(r backendReport) string { return fmt.Sprint(r.Constraints) })
+	row("compile", func(r backendReport) string { return r.Compile.Round(time.Millisecond).String() })
+	row("setup", func(r backendReport) string { return r.Setup.Round(time.Millisecond).String() })
+	row("prove", func(r backendReport) string { return r.Prove.Round(time.Millisecond).String() })
+	row("verify", func(r backendReport) string { return r.Verify.Round(time.Microsecond).String() })
+	row("proof (bytes)", func(r backendReport) string { return fmt.Sprint(r.ProofSize) })
+	row("proving key (bytes)", func(r backendReport) string { return fmt.Sprint(r.PKSize) })
+	row("verifying key (bytes)", func(r backendReport) string { return fmt.Sprint(r.VKSize) })
+	tw.Flush()
+}
@@ -0,0 +1,6 @@
+// Package difftest so sánh TxsFieldCircuit với cách cosmos-sdk decode tx: mọi
+// field mà ProtoCodec.UnpackAny trả về phải chứng minh được, mọi field nó
+// không trả về thì không. Với bố cục không chuẩn mà SDK vẫn decode (field
+// TxRaw, Fee hay sub-message singular lặp lại), circuit được phép từ chối
+// nhưng không được chứng minh giá trị khác SDK. Package chỉ có test.
+package difftest
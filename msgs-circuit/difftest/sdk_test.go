@@ -0,0 +1,804 @@
+package difftest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DongLieu/msg-circuit/txcodec"
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+	txswitness "github.com/DongLieu/msg-circuit/txscircuit/witness"
+
+	"cosmossdk.io/math"
+	banktypes "cosmossdk.io/x/bank/types"
+	stakingtypes "cosmossdk.io/x/staking/types"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/std"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// shadowedValueLen là sức chứa value khi thử chứng minh occurrence bị che, để
+// value giả có thể dài ngắn khác value thật.
+const shadowedValueLen = 96
+
+// reportedField là một field SDK trả về sau UnpackAny; Present = false khi
+// field mang giá trị mặc định (proto3 không encode). Với field repeated
+// (amount, funds) mỗi phần tử là một reportedField riêng, Spec chọn phần tử đó
+// bằng chỉ số.
+type reportedField struct {
+	Spec    txswitness.FieldSpec
+	Value   []byte
+	Present bool
+}
+
+func newCodec() *codec.ProtoCodec {
+	registry := codectypes.NewInterfaceRegistry()
+	std.RegisterInterfaces(registry)
+	banktypes.RegisterInterfaces(registry)
+	stakingtypes.RegisterInterfaces(registry)
+	txcodec.RegisterExecuteContract(registry)
+	return codec.NewProtoCodec(registry)
+}
+
+func TestCircuitMatchesSDKDecoding(t *testing.T) {
+	numTxs := 40
+	if testing.Short() {
+		numTxs = 8
+	}
+	cdc := newCodec()
+	resolver := txswitness.NewRegistryResolver(cdc.InterfaceRegistry())
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for n := 0; n < numTxs; n++ {
+		txBytes := randomTx(t, cdc, rng)
+		reported := decodeReported(t, cdc, txBytes)
+		for i := range reported {
+			for _, field := range reported[i] {
+				name := fmt.Sprintf("tx%d/msg%d/%s", n, i, specName(field.Spec))
+				checkField(t, name, txBytes, reported, i, field, resolver)
+			}
+		}
+	}
+}
+
+// checkField chứng minh field của message msgIndex (các message khác dùng
+// field đầu tiên của chúng) và đối chiếu với giá trị SDK trả về.
+func checkField(t *testing.T, name string, txBytes []byte, reported [][]reportedField, msgIndex int, field reportedField, resolver txswitness.FieldResolver) {
+	t.Helper()
+	specs := fieldSpecs(reported, msgIndex, field.Spec)
+	assignment, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, specs, resolver)
+	if !field.Present {
+		if !errors.Is(err, txswitness.ErrFieldNotFound) {
+			t.Errorf("%s: SDK reports no value but assignment err = %v", name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s: SDK reports %q but assignment failed: %v", name, field.Value, err)
+		return
+	}
+
+	msg := &assignment.Msgs[msgIndex]
+	if got := variableBytes(msg.Field.Value); string(got) != string(field.Value) {
+		t.Errorf("%s: witness value %q, SDK value %q", name, got, field.Value)
+		return
+	}
+	if err := solve(txBytes, configs, assignment); err != nil {
+		t.Errorf("%s: field reported by the SDK is not provable: %v", name, err)
+		return
+	}
+
+	// Value SDK không trả về: lật byte cuối.
+	last := len(field.Value) - 1
+	msg.Field.Value[last] = field.Value[last] ^ 0x01
+	if err := solve(txBytes, configs, assignment); err == nil {
+		t.Errorf("%s: circuit accepted a value the SDK does not report", name)
+	}
+	msg.Field.Value[last] = field.Value[last]
+
+	checkShadowed(t, name, txBytes, specs, msgIndex, field, resolver)
+}
+
+// fieldSpecs chọn spec cho message msgIndex, các message khác dùng field đầu
+// tiên của chúng.
+func fieldSpecs(reported [][]reportedField, msgIndex int, spec txswitness.FieldSpec) []txswitness.FieldSpec {
+	specs := make([]txswitness.FieldSpec, len(reported))
+	for i := range reported {
+		specs[i] = reported[i][0].Spec
+		specs[i].MsgIndex = i
+	}
+	specs[msgIndex] = spec
+	specs[msgIndex].MsgIndex = msgIndex
+	return specs
+}
+
+// checkShadowed thử trỏ witness vào từng occurrence khác của bước đầu tiên
+// trên đường dẫn, giữ nguyên public input: bản sao trước của field singular
+// (SDK ghi đè, không trả về) hoặc phần tử khác của field repeated (SDK trả về
+// ở chỉ số khác).
+func checkShadowed(t *testing.T, name string, txBytes []byte, specs []txswitness.FieldSpec, msgIndex int, field reportedField, resolver txswitness.FieldResolver) {
+	t.Helper()
+	numbers := fieldNumbers(t, field.Spec)
+	occ := occurrences(anyValue(t, txBytes, msgIndex), numbers[0])
+	if len(occ) < 2 {
+		return
+	}
+	target := len(occ) - 1
+	if index, ok := firstIndex(field.Spec); ok {
+		target = index
+	}
+
+	specs[msgIndex].MaxValueLen = shadowedValueLen
+	assignment, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, specs, resolver)
+	if err != nil {
+		t.Fatalf("%s: padded assignment: %v", name, err)
+	}
+	msg := &assignment.Msgs[msgIndex]
+
+	for k, region := range occ {
+		if k == target {
+			continue
+		}
+		if len(numbers) > 1 {
+			// Bước sau lấy occurrence cuối trong sub-message kia.
+			inner := occurrences(region.Payload, numbers[1])
+			if len(inner) == 0 {
+				continue
+			}
+			msg.Path[0].Offset = region.Offset
+			region = inner[len(inner)-1]
+		}
+		other := region.Payload
+		msg.FieldOffset = region.Offset
+		padBytes(msg.Field.Value, other)
+		msg.Field.Len = len(other)
+		if err := solve(txBytes, configs, assignment); err == nil {
+			t.Errorf("%s: circuit accepted occurrence %d (%q) in place of occurrence %d", name, k, other, target)
+		}
+	}
+}
+
+// TestCircuitNonCanonicalLayouts dựng các bố cục SDK vẫn decode được nhưng
+// randomTx không sinh ra: body_bytes, auth_info_bytes, Fee và
+// MsgDelegate.amount lặp lại. Với mỗi bố cục, circuit phải từ chối hoặc chứng
+// minh đúng giá trị SDK decode ra, kể cả khi witness trỏ vào occurrence bị che.
+func TestCircuitNonCanonicalLayouts(t *testing.T) {
+	numTxs := 12
+	if testing.Short() {
+		numTxs = 3
+	}
+	cdc := newCodec()
+	resolver := txswitness.NewRegistryResolver(cdc.InterfaceRegistry())
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for n := 0; n < numTxs; n++ {
+		parts := randomTxParts(t, cdc, rng)
+		decoy := randomTxParts(t, cdc, rng)
+		body, auth, sig := rawField{1, parts.Body}, rawField{2, parts.AuthInfo}, rawField{3, parts.Sig}
+
+		// Đối chứng: Fee của tx chuẩn chứng minh được.
+		name := fmt.Sprintf("tx%d/canonical", n)
+		if proved := checkFee(t, name, cdc, encodeTxRaw(body, auth, sig), resolver); proved != 1 {
+			t.Errorf("%s: fee proved %d times, want 1", name, proved)
+		}
+
+		// [body mồi][body][auth_info][sig]: SDK dùng body_bytes cuối.
+		name = fmt.Sprintf("tx%d/dup-body", n)
+		dupBody := encodeTxRaw(rawField{1, decoy.Body}, body, auth, sig)
+		checkLayout(t, name, cdc, dupBody, resolver)
+		checkDecoyBody(t, name, cdc, encodeTxRaw(rawField{1, decoy.Body}, auth, sig), dupBody, resolver)
+
+		// [body][auth_info mồi][auth_info][sig]: SDK dùng auth_info_bytes cuối.
+		name = fmt.Sprintf("tx%d/dup-auth-info", n)
+		dupAuthInfo := encodeTxRaw(body, rawField{2, decoy.AuthInfo}, auth, sig)
+		checkLayout(t, name, cdc, dupAuthInfo, resolver)
+		checkFee(t, name, cdc, dupAuthInfo, resolver)
+
+		// AuthInfo{fee mồi, fee}: SDK merge hai Fee (nối amount, gas_limit cuối).
+		name = fmt.Sprintf("tx%d/dup-fee", n)
+		authInfo := protowire.AppendBytes(protowire.AppendTag(nil, 2, protowire.BytesType), decoy.Fee)
+		dupFee := encodeTxRaw(body, rawField{2, append(authInfo, parts.AuthInfo...)}, sig)
+		checkLayout(t, name, cdc, dupFee, resolver)
+		checkFee(t, name, cdc, dupFee, resolver)
+
+		// MsgDelegate.amount lặp lại trong một message chèn thêm vào body.
+		name = fmt.Sprintf("tx%d/dup-amount", n)
+		bodyBytes, msgIndex := withDuplicatedAmount(t, cdc, rng, parts.Body)
+		dupAmount := encodeTxRaw(rawField{1, bodyBytes}, auth, sig)
+		checkLayout(t, name, cdc, dupAmount, resolver)
+		checkMergedAmount(t, name, cdc, dupAmount, msgIndex, resolver)
+	}
+}
+
+// checkLayout đối chiếu mọi field SDK decode ra từ tx không chuẩn: builder
+// được phép từ chối bố cục (ErrUnsupportedTx, ErrMalformedTx), còn nếu dựng
+// được witness thì field phải khớp SDK và chứng minh được như với tx chuẩn.
+func checkLayout(t *testing.T, name string, cdc *codec.ProtoCodec, txBytes []byte, resolver txswitness.FieldResolver) {
+	t.Helper()
+	reported := decodeReported(t, cdc, txBytes)
+	for i := range reported {
+		for _, field := range reported[i] {
+			_, _, err := txswitness.BuildAssignmentWithResolver(txBytes, fieldSpecs(reported, i, field.Spec), resolver)
+			if errors.Is(err, txswitness.ErrUnsupportedTx) || errors.Is(err, txswitness.ErrMalformedTx) {
+				continue
+			}
+			checkField(t, fmt.Sprintf("%s/msg%d/%s", name, i, specName(field.Spec)), txBytes, reported, i, field, resolver)
+		}
+	}
+}
+
+// checkDecoyBody dựng witness cho các message của body mồi trên decoyTx
+// ([body mồi][auth_info][sig]) rồi đặt nó lên txBytes, nơi body mồi đứng trước
+// body thật: offset của mọi message trùng nhau nên chỉ public tx thay đổi. SDK
+// decode body thật (khác body mồi) nên circuit phải từ chối.
+func checkDecoyBody(t *testing.T, name string, cdc *codec.ProtoCodec, decoyTx, txBytes []byte, resolver txswitness.FieldResolver) {
+	t.Helper()
+	reported := decodeReported(t, cdc, decoyTx)
+	assignment, configs, err := txswitness.BuildAssignmentWithResolver(decoyTx, fieldSpecs(reported, 0, reported[0][0].Spec), resolver)
+	if err != nil {
+		t.Fatalf("%s: decoy assignment: %v", name, err)
+	}
+	assignment.PublicTxBytes = make([]frontend.Variable, len(txBytes))
+	padBytes(assignment.PublicTxBytes, txBytes)
+	assignment.TxLen = len(txBytes)
+	if err := solve(txBytes, configs, assignment); err == nil {
+		t.Errorf("%s: circuit accepted the messages of a shadowed body_bytes", name)
+	}
+}
+
+// checkFee thử chứng minh từng Fee trong auth_info_bytes mà circuit đọc (ngay
+// sau body_bytes) và trả về số Fee được chấp nhận. Fee được chấp nhận phải
+// khớp Fee SDK decode: auth_info_bytes cuối, các Fee lặp lại đã merge.
+func checkFee(t *testing.T, name string, cdc *codec.ProtoCodec, txBytes []byte, resolver txswitness.FieldResolver) int {
+	t.Helper()
+	var raw txtypes.TxRaw
+	if err := cdc.Unmarshal(txBytes, &raw); err != nil {
+		t.Fatalf("%s: decode TxRaw: %v", name, err)
+	}
+	var authInfo txtypes.AuthInfo
+	if err := cdc.Unmarshal(raw.AuthInfoBytes, &authInfo); err != nil {
+		t.Fatalf("%s: decode AuthInfo: %v", name, err)
+	}
+	var want []string
+	for _, coin := range authInfo.Fee.Amount {
+		want = append(want, coin.Denom+":"+coin.Amount.String())
+	}
+
+	reported := decodeReported(t, cdc, txBytes)
+	specs := fieldSpecs(reported, 0, reported[0][0].Spec)
+	_, n := protowire.ConsumeBytes(txBytes[1:])
+	authRegion, _ := protowire.ConsumeBytes(txBytes[2+n:])
+
+	proved := 0
+	for k, fee := range occurrences(authRegion, 2) {
+		gasOffset, gas, ok := lastVarint(fee.Payload, 2)
+		if !ok {
+			continue
+		}
+		coins := occurrences(fee.Payload, 1)
+		cfg := txscircuit.AuthInfoConfig{NumFeeCoins: len(coins), MaxDenomLen: 128, MaxAmountLen: 32}
+		assignment, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, specs, resolver)
+		if err != nil {
+			t.Fatalf("%s: assignment: %v", name, err)
+		}
+		a := &assignment.WithAuthInfo(cfg).AuthInfo[0]
+		a.FeeOffset = fee.Offset
+		a.GasLimitOffset = gasOffset
+		a.GasLimit = gas
+		var claimed []string
+		for j, coin := range coins {
+			denom, amount := lastPayload(coin.Payload, 1), lastPayload(coin.Payload, 2)
+			a.FeeCoins[j].Offset = coin.Offset
+			a.FeeCoins[j].DenomLen = len(denom)
+			a.FeeCoins[j].AmountLen = len(amount)
+			padBytes(a.FeeCoins[j].Denom, denom)
+			padBytes(a.FeeCoins[j].Amount, amount)
+			claimed = append(claimed, string(denom)+":"+string(amount))
+		}
+
+		circuit := txscircuit.NewTxsFieldCircuit(len(txBytes), configs).WithAuthInfo(cfg)
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			continue
+		}
+		proved++
+		if !slices.Equal(claimed, want) || gas != authInfo.Fee.GasLimit {
+			t.Errorf("%s: circuit accepted fee %d %v gas %d, SDK decodes %v gas %d", name, k, claimed, gas, want, authInfo.Fee.GasLimit)
+		}
+	}
+	return proved
+}
+
+// checkMergedAmount trỏ witness vào từng occurrence của MsgDelegate.amount
+// trong message msgIndex, khi chứng minh chính Coin (shape MessageField như
+// builder dựng cho spec "amount") lẫn denom/amount bên trong. SDK merge các
+// occurrence nên circuit phải từ chối, trừ khi giá trị trùng giá trị SDK trả
+// về.
+func checkMergedAmount(t *testing.T, name string, cdc *codec.ProtoCodec, txBytes []byte, msgIndex int, resolver txswitness.FieldResolver) {
+	t.Helper()
+	reported := decodeReported(t, cdc, txBytes)
+	sdkValues := make(map[string][]byte)
+	for _, field := range reported[msgIndex] {
+		sdkValues[field.Spec.Path] = field.Value
+	}
+	specs := fieldSpecs(reported, msgIndex, reported[msgIndex][0].Spec)
+
+	type claim struct {
+		path    string
+		step    bool
+		key     byte
+		offset  int
+		payload []byte
+	}
+	for k, coin := range occurrences(anyValue(t, txBytes, msgIndex), 3) {
+		claims := []claim{{path: "amount", key: 0x1a, offset: coin.Offset, payload: coin.Payload}}
+		for number, path := range []string{1: "amount.denom", 2: "amount.amount"} {
+			inner := occurrences(coin.Payload, number)
+			if path == "" || len(inner) == 0 {
+				continue
+			}
+			last := inner[len(inner)-1]
+			claims = append(claims, claim{path: path, step: true, key: byte(number<<3 | 2), offset: last.Offset, payload: last.Payload})
+		}
+
+		for _, c := range claims {
+			assignment, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, specs, resolver)
+			if err != nil {
+				t.Fatalf("%s: assignment: %v", name, err)
+			}
+			cfg := &configs[msgIndex]
+			cfg.FieldValueLen, cfg.MaxFieldValueLen = 0, shadowedValueLen
+			cfg.MessageField = !c.step
+			msg := &assignment.Msgs[msgIndex]
+			msg.Path = nil
+			cfg.PathDepth = 0
+			if c.step {
+				cfg.PathDepth = 1
+				msg.Path = []txscircuit.PathStep{{Key: 0x1a, Index: 0, Offset: coin.Offset}}
+			}
+			msg.Field = txscircuit.FieldPublic{Key: c.key, Value: make([]frontend.Variable, shadowedValueLen), Len: len(c.payload), Varint: 0, Index: 0}
+			padBytes(msg.Field.Value, c.payload)
+			msg.FieldOffset = c.offset
+
+			if err := solve(txBytes, configs, assignment); err == nil && !bytes.Equal(c.payload, sdkValues[c.path]) {
+				t.Errorf("%s: circuit accepted %s %q from occurrence %d, SDK decodes %q", name, c.path, c.payload, k, sdkValues[c.path])
+			}
+		}
+	}
+}
+
+// firstIndex trả về chỉ số phần tử ở bước đầu tiên của spec nếu bước đó là
+// field repeated.
+func firstIndex(spec txswitness.FieldSpec) (int, bool) {
+	if spec.Path == "" {
+		if len(spec.Indices) > 0 && spec.Indices[0] >= 0 {
+			return spec.Indices[0], true
+		}
+		return 0, false
+	}
+	first, _, _ := strings.Cut(spec.Path, ".")
+	index, err := strconv.Atoi(strings.Trim(elementIndex.FindString(first), "[]"))
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+func solve(txBytes []byte, configs []txscircuit.MsgConfig, assignment *txscircuit.TxsFieldCircuit) error {
+	return test.IsSolved(txscircuit.NewTxsFieldCircuit(len(txBytes), configs), assignment, ecc.BN254.ScalarField())
+}
+
+// decodeReported decode tx như node rồi liệt kê field SDK trả về của từng
+// message.
+func decodeReported(t *testing.T, cdc *codec.ProtoCodec, txBytes []byte) [][]reportedField {
+	t.Helper()
+	msgs := decodeMsgs(t, cdc, txBytes)
+	reported := make([][]reportedField, len(msgs))
+	for i, msg := range msgs {
+		reported[i] = reportedFields(t, msg)
+	}
+	return reported
+}
+
+// decodeMsgs decode tx như node: TxRaw → TxBody → UnpackAny từng message.
+func decodeMsgs(t *testing.T, cdc *codec.ProtoCodec, txBytes []byte) []sdk.Msg {
+	t.Helper()
+	var raw txtypes.TxRaw
+	if err := cdc.Unmarshal(txBytes, &raw); err != nil {
+		t.Fatalf("decode TxRaw: %v", err)
+	}
+	var body txtypes.TxBody
+	if err := cdc.Unmarshal(raw.BodyBytes, &body); err != nil {
+		t.Fatalf("decode TxBody: %v", err)
+	}
+	msgs := make([]sdk.Msg, len(body.Messages))
+	for i, anyMsg := range body.Messages {
+		if err := cdc.UnpackAny(anyMsg, &msgs[i]); err != nil {
+			t.Fatalf("unpack %s: %v", anyMsg.TypeUrl, err)
+		}
+	}
+	return msgs
+}
+
+// reportedFields liệt kê các field SDK trả về cho msg, kể cả field rỗng (proto3
+// không encode nên không có gì để chứng minh).
+func reportedFields(t *testing.T, msg sdk.Msg) []reportedField {
+	t.Helper()
+	switch m := msg.(type) {
+	case *banktypes.MsgSend:
+		fields := []reportedField{
+			stringField("from_address", m.FromAddress),
+			stringField("to_address", m.ToAddress),
+		}
+		return append(fields, coinFields(t, "amount", m.Amount, true)...)
+	case *stakingtypes.MsgDelegate:
+		fields := []reportedField{
+			stringField("delegator_address", m.DelegatorAddress),
+			stringField("validator_address", m.ValidatorAddress),
+		}
+		return append(fields, coinFields(t, "amount", sdk.Coins{m.Amount}, false)...)
+	case *txcodec.MsgExecuteContract:
+		// Không có descriptor cho type dựng tay: chọn field bằng field number.
+		fields := []reportedField{
+			stringField("1", m.Sender),
+			stringField("2", m.Contract),
+			stringField("3", string(m.Msg)),
+		}
+		return append(fields, coinFields(t, "5", m.Funds, true)...)
+	default:
+		t.Fatalf("unexpected msg %T", msg)
+		return nil
+	}
+}
+
+// stringField: name là tên field hoặc field number (xem specFromName).
+func stringField(name, value string) reportedField {
+	return reportedField{Spec: specFromName(name), Value: []byte(value), Present: value != ""}
+}
+
+// coinFields trả về field coin cùng denom/amount của từng coin; field repeated
+// chọn coin bằng chỉ số, field singular chỉ có một coin.
+func coinFields(t *testing.T, name string, coins sdk.Coins, repeated bool) []reportedField {
+	t.Helper()
+	if len(coins) == 0 {
+		// Chỉ field repeated mới rỗng được: không có phần tử nào.
+		return []reportedField{{Spec: specFromName(name + "[0]")}}
+	}
+
+	var fields []reportedField
+	for k, coin := range coins {
+		elem := name
+		if repeated {
+			elem = fmt.Sprintf("%s[%d]", name, k)
+		}
+		denomName, amountName := elem+".denom", elem+".amount"
+		if strings.HasPrefix(elem, "5") {
+			denomName, amountName = elem+".1", elem+".2"
+		}
+		bz, err := coin.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields = append(fields,
+			reportedField{Spec: specFromName(elem), Value: bz, Present: true},
+			stringField(denomName, coin.Denom),
+			stringField(amountName, coin.Amount.String()),
+		)
+	}
+	return fields
+}
+
+// specFromName: "amount[0].denom" là Path, "5[0].1" là FieldNumbers với
+// Indices.
+func specFromName(name string) txswitness.FieldSpec {
+	var spec txswitness.FieldSpec
+	for _, part := range strings.Split(name, ".") {
+		n, index := 0, -1
+		if _, err := fmt.Sscanf(part, "%d[%d]", &n, &index); err != nil && index < 0 {
+			if _, err := fmt.Sscanf(part, "%d", &n); err != nil {
+				return txswitness.FieldSpec{Path: name}
+			}
+		}
+		spec.FieldNumbers = append(spec.FieldNumbers, n)
+		spec.Indices = append(spec.Indices, index)
+	}
+	return spec
+}
+
+func specName(spec txswitness.FieldSpec) string {
+	if spec.Path != "" {
+		return spec.Path
+	}
+	parts := make([]string, len(spec.FieldNumbers))
+	for d, n := range spec.FieldNumbers {
+		parts[d] = fmt.Sprint(n)
+		if spec.Indices[d] >= 0 {
+			parts[d] += fmt.Sprintf("[%d]", spec.Indices[d])
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+var elementIndex = regexp.MustCompile(`\[\d+\]`)
+
+// fieldNumbers đổi spec về field number của từng bước (Path chỉ dùng tên của
+// MsgSend/MsgDelegate, đều có amount = 3, denom = 1, amount = 2).
+func fieldNumbers(t *testing.T, spec txswitness.FieldSpec) []int {
+	t.Helper()
+	if spec.Path == "" {
+		return spec.FieldNumbers
+	}
+	names := map[string][]int{
+		"from_address": {1}, "to_address": {2}, "delegator_address": {1}, "validator_address": {2},
+		"amount": {3}, "amount.denom": {3, 1}, "amount.amount": {3, 2},
+	}
+	numbers, ok := names[elementIndex.ReplaceAllString(spec.Path, "")]
+	if !ok {
+		t.Fatalf("no field numbers for %q", spec.Path)
+	}
+	return numbers
+}
+
+// occurrence là một field trong message value; Offset tính từ đầu value như
+// MsgAssertion.FieldOffset / PathStep.Offset.
+type occurrence struct {
+	Offset  int
+	Payload []byte
+}
+
+func occurrences(value []byte, number int) []occurrence {
+	var out []occurrence
+	for pos := 0; pos < len(value); {
+		num, typ, n := protowire.ConsumeTag(value[pos:])
+		if n < 0 {
+			return out
+		}
+		m := protowire.ConsumeFieldValue(num, typ, value[pos+n:])
+		if m < 0 {
+			return out
+		}
+		if int(num) == number && typ == protowire.BytesType {
+			payload, _ := protowire.ConsumeBytes(value[pos+n:])
+			out = append(out, occurrence{Offset: pos, Payload: payload})
+		}
+		pos += n + m
+	}
+	return out
+}
+
+// lastPayload trả về payload của occurrence cuối cùng của field number, nil
+// khi không có.
+func lastPayload(value []byte, number int) []byte {
+	occ := occurrences(value, number)
+	if len(occ) == 0 {
+		return nil
+	}
+	return occ[len(occ)-1].Payload
+}
+
+// lastVarint trả về offset và giá trị của occurrence cuối cùng của field
+// varint number.
+func lastVarint(value []byte, number int) (offset int, v uint64, ok bool) {
+	for pos := 0; pos < len(value); {
+		num, typ, n := protowire.ConsumeTag(value[pos:])
+		if n < 0 {
+			break
+		}
+		m := protowire.ConsumeFieldValue(num, typ, value[pos+n:])
+		if m < 0 {
+			break
+		}
+		if int(num) == number && typ == protowire.VarintType {
+			offset, ok = pos, true
+			v, _ = protowire.ConsumeVarint(value[pos+n:])
+		}
+		pos += n + m
+	}
+	return offset, v, ok
+}
+
+// anyValue trả về Any.value của message msgIndex trong tx.
+func anyValue(t *testing.T, txBytes []byte, msgIndex int) []byte {
+	t.Helper()
+	var raw txtypes.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		t.Fatal(err)
+	}
+	var body txtypes.TxBody
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		t.Fatal(err)
+	}
+	return body.Messages[msgIndex].Value
+}
+
+// padBytes ghi data vào đầu dst và pad 0 phần còn lại.
+func padBytes(dst []frontend.Variable, data []byte) {
+	for j := range dst {
+		dst[j] = 0
+		if j < len(data) {
+			dst[j] = data[j]
+		}
+	}
+}
+
+func variableBytes(vars []frontend.Variable) []byte {
+	out := make([]byte, len(vars))
+	for i, v := range vars {
+		b, _ := v.(byte)
+		out[i] = b
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Sinh tx.
+
+var denoms = []string{"uatom", "stake", "uosmo", "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"}
+
+func randomTx(t *testing.T, cdc *codec.ProtoCodec, rng *rand.Rand) []byte {
+	t.Helper()
+	parts := randomTxParts(t, cdc, rng)
+	txBytes, err := cdc.Marshal(&txtypes.TxRaw{BodyBytes: parts.Body, AuthInfoBytes: parts.AuthInfo, Signatures: [][]byte{parts.Sig}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txBytes
+}
+
+// txParts là các field của TxRaw trước khi ghép, để dựng cả bố cục chuẩn lẫn
+// bố cục lặp field. Fee là Fee đã encode, AuthInfo = AuthInfo{fee: Fee}.
+type txParts struct {
+	Body, AuthInfo, Fee, Sig []byte
+}
+
+func randomTxParts(t *testing.T, cdc *codec.ProtoCodec, rng *rand.Rand) txParts {
+	t.Helper()
+	numMsgs := 1 + rng.IntN(3)
+	msgs := make([]*codectypes.Any, numMsgs)
+	for i := range msgs {
+		msgs[i] = randomMsg(t, rng)
+	}
+
+	body := &txtypes.TxBody{Messages: msgs, Memo: strings.Repeat("m", rng.IntN(40))}
+	if rng.IntN(3) == 0 {
+		body.TimeoutHeight = rng.Uint64N(1 << 40)
+	}
+	fee := &txtypes.Fee{
+		Amount:   randomCoins(rng, 1),
+		GasLimit: 100000 + rng.Uint64N(1000000),
+	}
+	var parts txParts
+	var err error
+	if parts.Body, err = cdc.Marshal(body); err != nil {
+		t.Fatal(err)
+	}
+	if parts.AuthInfo, err = cdc.Marshal(&txtypes.AuthInfo{Fee: fee}); err != nil {
+		t.Fatal(err)
+	}
+	if parts.Fee, err = cdc.Marshal(fee); err != nil {
+		t.Fatal(err)
+	}
+	parts.Sig = randomBytes(rng, 64)
+	return parts
+}
+
+// rawField là một field length-delimited của TxRaw.
+type rawField struct {
+	Number protowire.Number
+	Value  []byte
+}
+
+// encodeTxRaw ghép các field theo đúng thứ tự cho trước, kể cả field lặp lại.
+func encodeTxRaw(fields ...rawField) []byte {
+	var out []byte
+	for _, f := range fields {
+		out = protowire.AppendTag(out, f.Number, protowire.BytesType)
+		out = protowire.AppendBytes(out, f.Value)
+	}
+	return out
+}
+
+// withDuplicatedAmount chèn vào body một MsgDelegate có amount lặp lại; bản
+// sau chỉ có amount nên SDK merge thành Coin{denom bản đầu, amount bản sau}.
+// Trả về body mới và vị trí của message chèn vào.
+func withDuplicatedAmount(t *testing.T, cdc *codec.ProtoCodec, rng *rand.Rand, bodyBytes []byte) ([]byte, int) {
+	t.Helper()
+	var body txtypes.TxBody
+	if err := cdc.Unmarshal(bodyBytes, &body); err != nil {
+		t.Fatal(err)
+	}
+	anyMsg, err := codectypes.NewAnyWithValue(&stakingtypes.MsgDelegate{
+		DelegatorAddress: randomAddress(rng),
+		ValidatorAddress: sdk.ValAddress(randomBytes(rng, 20)).String(),
+		Amount:           randomCoins(rng, 1)[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial := protowire.AppendTag(nil, 2, protowire.BytesType)
+	partial = protowire.AppendString(partial, strconv.FormatUint(rng.Uint64N(1<<50), 10))
+	anyMsg.Value = protowire.AppendTag(anyMsg.Value, 3, protowire.BytesType)
+	anyMsg.Value = protowire.AppendBytes(anyMsg.Value, partial)
+
+	msgIndex := rng.IntN(len(body.Messages) + 1)
+	body.Messages = slices.Insert(body.Messages, msgIndex, anyMsg)
+	out, err := cdc.Marshal(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, msgIndex
+}
+
+func randomMsg(t *testing.T, rng *rand.Rand) *codectypes.Any {
+	t.Helper()
+	from := randomAddress(rng)
+	var (
+		anyMsg *codectypes.Any
+		err    error
+	)
+	switch rng.IntN(3) {
+	case 0:
+		msg := &banktypes.MsgSend{FromAddress: from, ToAddress: randomAddress(rng), Amount: randomCoins(rng, 3)}
+		if rng.IntN(5) == 0 {
+			msg.ToAddress = ""
+		}
+		anyMsg, err = codectypes.NewAnyWithValue(msg)
+		if err == nil && rng.IntN(4) == 0 {
+			// from_address lặp lại: SDK ghi đè bằng bản cuối.
+			anyMsg.Value = protowire.AppendTag(anyMsg.Value, 1, protowire.BytesType)
+			anyMsg.Value = protowire.AppendString(anyMsg.Value, randomAddress(rng))
+		}
+	case 1:
+		anyMsg, err = codectypes.NewAnyWithValue(&stakingtypes.MsgDelegate{
+			DelegatorAddress: from,
+			ValidatorAddress: sdk.ValAddress(randomBytes(rng, 20)).String(),
+			Amount:           randomCoins(rng, 1)[0],
+		})
+	default:
+		payload := fmt.Sprintf(`{"transfer":{"recipient":%q,"amount":"%d"}}`, randomAddress(rng), rng.Uint64())
+		if rng.IntN(5) == 0 {
+			payload = ""
+		}
+		anyMsg, err = txcodec.BuildExecuteContractAny(from, randomAddress(rng), []byte(payload), randomCoins(rng, 2))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return anyMsg
+}
+
+// randomCoins trả về 1..max coin (ít nhất 1 khi max = 1), hoặc đôi khi rỗng.
+func randomCoins(rng *rand.Rand, max int) sdk.Coins {
+	n := 1 + rng.IntN(max)
+	if max > 1 && rng.IntN(6) == 0 {
+		n = 0
+	}
+	coins := make(sdk.Coins, 0, n)
+	for _, i := range rng.Perm(len(denoms))[:min(n, len(denoms))] {
+		coins = append(coins, sdk.NewCoin(denoms[i], math.NewIntFromUint64(rng.Uint64N(1<<50))))
+	}
+	return sdk.NewCoins(coins...)
+}
+
+func randomAddress(rng *rand.Rand) string {
+	return sdk.AccAddress(randomBytes(rng, 20)).String()
+}
+
+func randomBytes(rng *rand.Rand, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(rng.Uint32())
+	}
+	return out
+}
@@ -4,11 +4,15 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/DongLieu/msg-circuit/txcodec"
 	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+	txswitness "github.com/DongLieu/msg-circuit/txscircuit/witness"
 
 	"cosmossdk.io/math"
 	banktypes "cosmossdk.io/x/bank/types"
@@ -16,7 +20,6 @@ import (
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
@@ -29,10 +32,14 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go <case>")
+		fmt.Println("Usage: go run . <case> | prove-tx | verify-tx | compare-backends | aggregate")
 		fmt.Println("  case 1: Legitimate transaction")
 		fmt.Println("  case 2: Duplicate field attack")
 		fmt.Println("  case 3: MsgExecuteContract with huge payload")
+		fmt.Println("  prove-tx: Prove fields of a tx given as hex/base64/file")
+		fmt.Println("  verify-tx: Verify a proof written by prove-tx")
+		fmt.Println("  compare-backends: Compare Groth16 and PLONK on a tx")
+		fmt.Println("  aggregate: Aggregate a directory of PLONK BLS12-377 proofs into one proof")
 		os.Exit(1)
 	}
 
@@ -44,20 +51,26 @@ func main() {
 		case2()
 	case "3":
 		case3()
+	case "prove-tx", "verify-tx", "compare-backends", "aggregate":
+		run := map[string]func([]string) error{
+			"prove-tx":         runProveTx,
+			"verify-tx":        runVerifyTx,
+			"compare-backends": runCompareBackends,
+			"aggregate":        runAggregate,
+		}[caseNum]
+		if err := run(os.Args[2:]); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Printf("%s: %v\n", caseNum, err)
+			}
+			os.Exit(1)
+		}
 	default:
 		fmt.Printf("Unknown case: %s\n", caseNum)
-		fmt.Println("Available cases: 1, 2, 3")
+		fmt.Println("Available cases: 1, 2, 3, prove-tx, verify-tx, compare-backends, aggregate")
 		os.Exit(1)
 	}
 }
 
-type txsFieldAssertion struct {
-	FieldKey    byte
-	FieldValue  []byte
-	FieldOffset int
-	BodyOffset  int
-}
-
 func case1() {
 	fmt.Println("========== ZK PROOF FOR MULTI-MSG FIELD VERIFICATION ==========")
 	fmt.Println()
@@ -100,76 +113,20 @@ func case1() {
 	fmt.Printf("Transaction created with %d bytes and %d messages\n", len(txBytes), len([]*codectypes.Any{sendAny, delegateAny}))
 	fmt.Println()
 
-	offsets, err := locateMessageOffsetsDemo(txBytes, len([]*codectypes.Any{sendAny, delegateAny}))
-	if err != nil {
-		panic(fmt.Errorf("locate offsets: %w", err))
-	}
-
-	sendFieldKey := byte((3 << 3) | 2)
-	sendFieldValue, err := extractFieldValueDemo(sendAny.Value, sendFieldKey)
-	if err != nil {
-		panic(err)
-	}
-	sendFieldOffset, err := findFieldOffset(sendAny.Value, sendFieldKey, sendFieldValue)
-	if err != nil {
-		panic(err)
-	}
-
-	delegateFieldKey := byte((1 << 3) | 2)
-	delegateFieldValue, err := extractFieldValueDemo(delegateAny.Value, delegateFieldKey)
-	if err != nil {
-		panic(err)
-	}
-	delegateFieldOffset, err := findFieldOffset(delegateAny.Value, delegateFieldKey, delegateFieldValue)
+	fmt.Println("Preparing witness...")
+	witness, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, []txswitness.FieldSpec{
+		{MsgIndex: 0, Path: "amount[0]"},
+		{MsgIndex: 1, Path: "delegator_address"},
+	}, txswitness.NewRegistryResolver(protoCodec.InterfaceRegistry()))
 	if err != nil {
-		panic(err)
-	}
-
-	assertions := []txsFieldAssertion{
-		{
-			FieldKey:    sendFieldKey,
-			FieldValue:  sendFieldValue,
-			FieldOffset: sendFieldOffset,
-			BodyOffset:  offsets[0],
-		},
-		{
-			FieldKey:    delegateFieldKey,
-			FieldValue:  delegateFieldValue,
-			FieldOffset: delegateFieldOffset,
-			BodyOffset:  offsets[1],
-		},
-	}
-
-	configs := []txscircuit.MsgConfig{
-		{
-			FieldValueLen: len(sendFieldValue),
-			MsgValueLen:   len(sendAny.Value),
-		},
-		{
-			FieldValueLen: len(delegateFieldValue),
-			MsgValueLen:   len(delegateAny.Value),
-		},
+		panic(fmt.Errorf("build assignment: %w", err))
 	}
+	fmt.Println("Witness ready!")
 
 	circuit := txscircuit.NewTxsFieldCircuit(len(txBytes), configs)
 
-	fmt.Println("Compiling TxsFieldCircuit...")
-	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
-	if err != nil {
-		panic(fmt.Errorf("compile circuit: %w", err))
-	}
-	fmt.Printf("Circuit compiled, constraints: %d\n\n", ccs.GetNbConstraints())
-
-	fmt.Println("Running Groth16 setup...")
-	pk, vk, err := groth16.Setup(ccs)
-	if err != nil {
-		panic(fmt.Errorf("setup: %w", err))
-	}
-	fmt.Println("Setup done!")
-
-	fmt.Println("Preparing witness...")
-	witness := prepareTxsWitness(txBytes, assertions, configs)
-	fmt.Println("Witness ready!")
+	artifacts := loadOrSetupArtifacts(circuit)
+	ccs, pk, vk := artifacts.CS, artifacts.PK, artifacts.VK
 
 	fmt.Println("Generating proof...")
 	fullWitness, err := frontend.NewWitness(witness, ecc.BN254.ScalarField())
@@ -219,7 +176,7 @@ func case3() {
 		panic(fmt.Errorf("wrap send: %w", err))
 	}
 
-	execAny, err := buildExecuteContractAny(fromAddr, contractAddr, execPayload, sdk.NewCoins(sdk.NewCoin("uatom", math.NewInt(1))))
+	execAny, err := txcodec.BuildExecuteContractAny(fromAddr, contractAddr, execPayload, sdk.NewCoins(sdk.NewCoin("uatom", math.NewInt(1))))
 	if err != nil {
 		panic(fmt.Errorf("build execute msg: %w", err))
 	}
@@ -229,76 +186,22 @@ func case3() {
 	txBytes := buildTxWithMessagesDemo(protoCodec, privKey, anyMsgs)
 	fmt.Printf("Transaction created with %d bytes (huge contract payload)\n\n", len(txBytes))
 
-	offsets, err := locateMessageOffsetsDemo(txBytes, len(anyMsgs))
-	if err != nil {
-		panic(fmt.Errorf("locate offsets: %w", err))
-	}
-
-	sendFieldKey := byte((3 << 3) | 2)
-	sendFieldValue, err := extractFieldValueDemo(sendAny.Value, sendFieldKey)
-	if err != nil {
-		panic(err)
-	}
-	sendFieldOffset, err := findFieldOffset(sendAny.Value, sendFieldKey, sendFieldValue)
-	if err != nil {
-		panic(err)
-	}
-
-	execFieldKey := byte((1 << 3) | 2) // sender field
-	execFieldValue, err := extractFieldValueDemo(execAny.Value, execFieldKey)
-	if err != nil {
-		panic(err)
-	}
-	execFieldOffset, err := findFieldOffset(execAny.Value, execFieldKey, execFieldValue)
+	fmt.Println("Preparing witness...")
+	// MsgExecuteContract được dựng tay, không có trong registry nên chọn field
+	// bằng field number.
+	witness, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, []txswitness.FieldSpec{
+		{MsgIndex: 0, Path: "amount[0]"},
+		{MsgIndex: 1, FieldNumbers: []int{1}}, // MsgExecuteContract.sender
+	}, txswitness.NewRegistryResolver(protoCodec.InterfaceRegistry()))
 	if err != nil {
-		panic(err)
-	}
-
-	assertions := []txsFieldAssertion{
-		{
-			FieldKey:    sendFieldKey,
-			FieldValue:  sendFieldValue,
-			FieldOffset: sendFieldOffset,
-			BodyOffset:  offsets[0],
-		},
-		{
-			FieldKey:    execFieldKey,
-			FieldValue:  execFieldValue,
-			FieldOffset: execFieldOffset,
-			BodyOffset:  offsets[1],
-		},
-	}
-
-	configs := []txscircuit.MsgConfig{
-		{
-			FieldValueLen: len(sendFieldValue),
-			MsgValueLen:   len(sendAny.Value),
-		},
-		{
-			FieldValueLen: len(execFieldValue),
-			MsgValueLen:   len(execAny.Value),
-		},
+		panic(fmt.Errorf("build assignment: %w", err))
 	}
+	fmt.Println("Witness ready!")
 
 	circuit := txscircuit.NewTxsFieldCircuit(len(txBytes), configs)
 
-	fmt.Println("Compiling TxsFieldCircuit...")
-	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
-	if err != nil {
-		panic(fmt.Errorf("compile circuit: %w", err))
-	}
-	fmt.Printf("Circuit compiled, constraints: %d\n\n", ccs.GetNbConstraints())
-
-	fmt.Println("Running Groth16 setup...")
-	pk, vk, err := groth16.Setup(ccs)
-	if err != nil {
-		panic(fmt.Errorf("setup: %w", err))
-	}
-	fmt.Println("Setup done!")
-
-	fmt.Println("Preparing witness...")
-	witness := prepareTxsWitness(txBytes, assertions, configs)
-	fmt.Println("Witness ready!")
+	artifacts := loadOrSetupArtifacts(circuit)
+	ccs, pk, vk := artifacts.CS, artifacts.PK, artifacts.VK
 
 	fmt.Println("Generating proof...")
 	fullWitness, err := frontend.NewWitness(witness, ecc.BN254.ScalarField())
@@ -322,6 +225,24 @@ func case3() {
 	fmt.Println("✅ Proof verification SUCCEEDED for large payload!")
 }
 
+// loadOrSetupArtifacts lấy CS/PK/VK của circuit từ store; compile và chạy
+// Groth16 setup chỉ khi shape (độ dài tx, MsgConfig) chưa có trong store.
+func loadOrSetupArtifacts(circuit *txscircuit.TxsFieldCircuit) *txscircuit.Artifacts {
+	store := txscircuit.NewArtifactStore(txscircuit.DefaultStoreDir)
+	fmt.Printf("Loading artifacts from %s...\n", store.Path(circuit.ShapeKey()))
+	artifacts, cached, err := store.LoadOrSetup(circuit)
+	if err != nil {
+		panic(fmt.Errorf("load or setup: %w", err))
+	}
+	if cached {
+		fmt.Println("Reused cached circuit and keys!")
+	} else {
+		fmt.Println("Circuit compiled and Groth16 setup done!")
+	}
+	fmt.Printf("Constraints: %d\n\n", artifacts.CS.GetNbConstraints())
+	return artifacts
+}
+
 func newBenchmarkProtoCodec() *codec.ProtoCodec {
 	interfaceRegistry := codectypes.NewInterfaceRegistry()
 	std.RegisterInterfaces(interfaceRegistry)
@@ -404,165 +325,15 @@ func buildTxWithMessagesDemo(
 	return txBytes
 }
 
-func locateMessageOffsetsDemo(txBytes []byte, expected int) ([]int, error) {
-	if len(txBytes) < 3 {
-		return nil, fmt.Errorf("tx too short")
-	}
-	if txBytes[0] != 0x0a {
-		return nil, fmt.Errorf("invalid body tag")
-	}
-
-	bodyLen, consumed, err := decodeVarintDemo(txBytes[1:])
-	if err != nil {
-		return nil, err
-	}
-
-	bodyStart := 1 + consumed
-	if bodyStart+bodyLen > len(txBytes) {
-		return nil, fmt.Errorf("body overflow")
-	}
-
-	offsets := make([]int, 0, expected)
-	cursor := bodyStart
-	limit := bodyStart + bodyLen
-	for cursor < limit && len(offsets) < expected {
-		if txBytes[cursor] != 0x0a {
-			return nil, fmt.Errorf("unexpected tag at %d", cursor)
-		}
-		offsets = append(offsets, cursor)
-		cursor++
-
-		msgLen, consumed, err := decodeVarintDemo(txBytes[cursor:])
-		if err != nil {
-			return nil, err
-		}
-		cursor += consumed + msgLen
-	}
-
-	if len(offsets) != expected {
-		return nil, fmt.Errorf("expected %d msgs, found %d", expected, len(offsets))
-	}
-
-	return offsets, nil
-}
-
 func decodeVarintDemo(data []byte) (int, int, error) {
-	var value int
-	var shift uint
-	var i int
-	for i = 0; i < len(data); i++ {
-		b := data[i]
-		value |= int(b&0x7f) << shift
-		if b&0x80 == 0 {
-			return value, i + 1, nil
-		}
-		shift += 7
-		if shift > 28 {
-			return 0, 0, fmt.Errorf("varint too long")
-		}
-	}
-	return 0, 0, fmt.Errorf("incomplete varint")
-}
-
-func extractFieldValueDemo(msgValue []byte, fieldKey byte) ([]byte, error) {
-	for idx := 0; idx < len(msgValue); {
-		key := msgValue[idx]
-		idx++
-
-		length, consumed, err := decodeVarintDemo(msgValue[idx:])
-		if err != nil {
-			return nil, fmt.Errorf("decode length: %w", err)
-		}
-		idx += consumed
-
-		if idx+length > len(msgValue) {
-			return nil, fmt.Errorf("field overruns message")
-		}
-
-		value := msgValue[idx : idx+length]
-		if key == fieldKey {
-			buf := make([]byte, len(value))
-			copy(buf, value)
-			return buf, nil
-		}
-
-		idx += length
-	}
-
-	return nil, fmt.Errorf("field 0x%x not found", fieldKey)
-}
-
-func findFieldOffset(msgValue []byte, fieldKey byte, fieldValue []byte) (int, error) {
-	offset := 0
-	for offset < len(msgValue) {
-		if msgValue[offset] == fieldKey {
-			// Found the field key, now verify the value matches
-			offset++ // Skip key byte
-
-			// Decode length
-			length, consumed, err := decodeVarintDemo(msgValue[offset:])
-			if err != nil {
-				return 0, err
-			}
-			offset += consumed
-
-			// Check if value matches
-			if offset+length <= len(msgValue) {
-				actualValue := msgValue[offset : offset+length]
-				if len(actualValue) == len(fieldValue) {
-					match := true
-					for i := range actualValue {
-						if actualValue[i] != fieldValue[i] {
-							match = false
-							break
-						}
-					}
-					if match {
-						// Return offset relative to start of msgValue
-						// Need to return offset of the KEY byte
-						return offset - consumed - 1, nil
-					}
-				}
-			}
-			offset += length
-		} else {
-			// Skip this field
-			offset++ // Skip key
-			length, consumed, err := decodeVarintDemo(msgValue[offset:])
-			if err != nil {
-				return 0, err
-			}
-			offset += consumed + length
-		}
+	value, consumed, err := txscircuit.DecodeVarintN(data, txscircuit.MaxVarintLen)
+	if err != nil {
+		return 0, 0, err
 	}
-	return 0, fmt.Errorf("field with key 0x%x and matching value not found", fieldKey)
-}
-
-func buildExecuteContractAny(sender, contract string, execMsg []byte, funds sdk.Coins) (*codectypes.Any, error) {
-	buf := make([]byte, 0, len(sender)+len(contract)+len(execMsg)+128)
-	buf = appendLengthDelimitedField(buf, 1, []byte(sender))
-	buf = appendLengthDelimitedField(buf, 2, []byte(contract))
-	buf = appendLengthDelimitedField(buf, 3, execMsg)
-
-	for _, coin := range funds {
-		coinBytes, err := proto.Marshal(&coin)
-		if err != nil {
-			return nil, fmt.Errorf("marshal coin: %w", err)
-		}
-		buf = appendLengthDelimitedField(buf, 4, coinBytes)
+	if int(value) < 0 {
+		return 0, 0, fmt.Errorf("varint %d overflows int", value)
 	}
-
-	return &codectypes.Any{
-		TypeUrl: "/cosmwasm.wasm.v1.MsgExecuteContract",
-		Value:   buf,
-	}, nil
-}
-
-func appendLengthDelimitedField(buf []byte, fieldNumber int, value []byte) []byte {
-	key := byte((fieldNumber << 3) | 2)
-	buf = append(buf, key)
-	buf = append(buf, encodeVarint(len(value))...)
-	return append(buf, value...)
+	return int(value), consumed, nil
 }
 
 func buildLargeExecutePayload(size int) []byte {
@@ -582,58 +353,6 @@ func buildLargeExecutePayload(size int) []byte {
 	return payload
 }
 
-func encodeVarint(length int) []byte {
-	if length == 0 {
-		return []byte{0}
-	}
-	var out []byte
-	val := length
-	for val > 0 {
-		b := byte(val & 0x7f)
-		val >>= 7
-		if val > 0 {
-			b |= 0x80
-		}
-		out = append(out, b)
-	}
-	return out
-}
-
-func prepareTxsWitness(
-	txBytes []byte,
-	assertions []txsFieldAssertion,
-	configs []txscircuit.MsgConfig,
-) *txscircuit.TxsFieldCircuit {
-	witness := txscircuit.NewTxsFieldCircuit(len(txBytes), configs)
-
-	for i := range witness.PublicTxBytes {
-		if i < len(txBytes) {
-			witness.PublicTxBytes[i] = int(txBytes[i])
-		} else {
-			witness.PublicTxBytes[i] = 0
-		}
-	}
-
-	for i, assertion := range assertions {
-		copyBytes := func(dst []frontend.Variable, data []byte) {
-			for j := range dst {
-				if j < len(data) {
-					dst[j] = int(data[j])
-				} else {
-					dst[j] = 0
-				}
-			}
-		}
-		copyBytes(witness.Msgs[i].Field.Value, assertion.FieldValue)
-
-		witness.Msgs[i].Field.Key = int(assertion.FieldKey)
-		witness.Msgs[i].FieldOffset = assertion.FieldOffset
-		witness.Msgs[i].BodyOffset = assertion.BodyOffset
-	}
-
-	return witness
-}
-
 func case2() {
 	fmt.Println("========== ATTACK: DUPLICATE FIELD TEST ==========")
 	fmt.Println()
@@ -672,6 +391,7 @@ func case2() {
 	// Strategy: Parse normalValue, find amount field, insert hidden field before it
 
 	maliciousValue := make([]byte, 0, len(normalValue)+len(hiddenCoinBytes)+2)
+	hiddenOffset := 0
 
 	// Copy fields up to amount field (field 1 and 2)
 	cursor := 0
@@ -693,7 +413,8 @@ func case2() {
 			maliciousValue = append(maliciousValue, byte(len(hiddenCoinBytes)))
 			maliciousValue = append(maliciousValue, hiddenCoinBytes...)
 
-			fmt.Printf("✓ Injected HIDDEN amount field: 10000uatom (at offset %d)\n", len(maliciousValue)-len(hiddenCoinBytes)-2)
+			hiddenOffset = len(maliciousValue) - len(hiddenCoinBytes) - 2
+			fmt.Printf("✓ Injected HIDDEN amount field: 10000uatom (at offset %d)\n", hiddenOffset)
 
 			// Then append the original amount field
 			maliciousValue = append(maliciousValue, tag)
@@ -749,90 +470,43 @@ func case2() {
 	fmt.Printf("Transaction created with %d bytes and %d messages\n", len(txBytes), 2)
 	fmt.Println()
 
-	offsets, err := locateMessageOffsetsDemo(txBytes, 2)
-	if err != nil {
-		panic(fmt.Errorf("locate offsets: %w", err))
-	}
-
-	// Extract the PUBLIC amount field (second occurrence, 4242uatom)
-	sendFieldKey := byte((3 << 3) | 2) // Field 3
-	sendFieldValue, err := extractFieldValueDemo(sendAny.Value, sendFieldKey)
+	// amount là repeated: tx gửi cả hai coin, builder chọn amount[1]
+	// (4242uatom); pad amount tới 32 byte để assignment giả mạo bên dưới dùng
+	// chung circuit.
+	witness, configs, err := txswitness.BuildAssignmentWithResolver(txBytes, []txswitness.FieldSpec{
+		{MsgIndex: 0, Path: "amount[1]", MaxValueLen: 32},
+		{MsgIndex: 1, Path: "delegator_address"},
+	}, txswitness.NewRegistryResolver(protoCodec.InterfaceRegistry()))
 	if err != nil {
-		panic(err)
-	}
-	fmt.Printf("✓ Extracted PUBLIC amount field: %d bytes\n", len(sendFieldValue))
-
-	// Find offset of PUBLIC amount (will find first occurrence = hidden one!)
-	sendFieldOffset, err := findFieldOffset(sendAny.Value, sendFieldKey, sendFieldValue)
-	if err != nil {
-		panic(err)
-	}
-	fmt.Printf("✓ PUBLIC amount offset: %d\n", sendFieldOffset)
-	fmt.Println()
-
-	delegateFieldKey := byte((1 << 3) | 2)
-	delegateFieldValue, err := extractFieldValueDemo(delegateAny.Value, delegateFieldKey)
-	if err != nil {
-		panic(err)
-	}
-	delegateFieldOffset, err := findFieldOffset(delegateAny.Value, delegateFieldKey, delegateFieldValue)
-	if err != nil {
-		panic(err)
-	}
-
-	assertions := []txsFieldAssertion{
-		{
-			FieldKey:    sendFieldKey,
-			FieldValue:  sendFieldValue,
-			FieldOffset: sendFieldOffset,
-			BodyOffset:  offsets[0],
-		},
-		{
-			FieldKey:    delegateFieldKey,
-			FieldValue:  delegateFieldValue,
-			FieldOffset: delegateFieldOffset,
-			BodyOffset:  offsets[1],
-		},
-	}
-
-	configs := []txscircuit.MsgConfig{
-		{
-			FieldValueLen: len(sendFieldValue),
-			MsgValueLen:   len(sendAny.Value),
-		},
-		{
-			FieldValueLen: len(delegateFieldValue),
-			MsgValueLen:   len(delegateAny.Value),
-		},
+		panic(fmt.Errorf("build assignment: %w", err))
 	}
 
 	circuit := txscircuit.NewTxsFieldCircuit(len(txBytes), configs)
 
-	fmt.Println("Compiling TxsFieldCircuit...")
-	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
-	if err != nil {
-		panic(fmt.Errorf("compile circuit: %w", err))
-	}
-	fmt.Printf("Circuit compiled, constraints: %d\n\n", ccs.GetNbConstraints())
-
-	fmt.Println("Running Groth16 setup...")
-	pk, vk, err := groth16.Setup(ccs)
-	if err != nil {
-		panic(fmt.Errorf("setup: %w", err))
+	artifacts := loadOrSetupArtifacts(circuit)
+	ccs, pk, vk := artifacts.CS, artifacts.PK, artifacts.VK
+
+	// ATTACK: trỏ assertion vào coin đầu tiên (HIDDEN) nhưng vẫn khẳng định
+	// đó là amount[1].
+	fmt.Println("Preparing witness with HIDDEN amount (10000uatom)...")
+	hiddenField := &witness.Msgs[0]
+	for i := range hiddenField.Field.Value {
+		hiddenField.Field.Value[i] = 0
+		if i < len(hiddenCoinBytes) {
+			hiddenField.Field.Value[i] = hiddenCoinBytes[i]
+		}
 	}
-	fmt.Println("Setup done!")
-
-	fmt.Println("Preparing witness with PUBLIC amount (4242uatom)...")
-	witness := prepareTxsWitness(txBytes, assertions, configs)
+	hiddenField.Field.Len = len(hiddenCoinBytes)
+	hiddenField.FieldOffset = hiddenOffset
 	fmt.Println("Witness ready!")
 
 	fmt.Println()
 	fmt.Println("🔴 ATTACK SCENARIO:")
-	fmt.Println("   - Message has DUPLICATE field 3 (amount)")
-	fmt.Println("   - First occurrence: 10000uatom (HIDDEN)")
-	fmt.Println("   - Second occurrence: 4242uatom (PUBLIC in proof)")
-	fmt.Println("   - Protobuf decoder will use LAST value = 4242uatom")
-	fmt.Println("   - But attacker proves the second occurrence")
+	fmt.Println("   - Message has TWO occurrences of field 3 (repeated amount)")
+	fmt.Println("   - amount[0]: 10000uatom (HIDDEN)")
+	fmt.Println("   - amount[1]: 4242uatom")
+	fmt.Println("   - Public input claims amount[1]")
+	fmt.Println("   - But attacker proves 10000uatom as amount[1]")
 	fmt.Println()
 
 	fmt.Println("Attempting to generate proof...")
@@ -844,7 +518,7 @@ func case2() {
 	if err != nil {
 		fmt.Printf("❌ PROOF GENERATION FAILED (as expected)!\n")
 		fmt.Printf("   Error: %v\n\n", err)
-		fmt.Println("✅ SUCCESS: Circuit detected duplicate field and rejected the proof!")
+		fmt.Println("✅ SUCCESS: Circuit detected the wrong amount element and rejected the proof!")
 		return
 	}
 
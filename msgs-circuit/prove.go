@@ -0,0 +1,472 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+	txswitness "github.com/DongLieu/msg-circuit/txscircuit/witness"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+)
+
+const (
+	proofFilename         = "txs_proof.bin"
+	publicWitnessFilename = "txs_public.wtns"
+	shapeKeyFilename      = "txs_shape.key"
+	shapeFilename         = "txs_shape.json"
+	bundleFilename        = "txs_proof.json"
+	backendFilename       = "txs_backend"
+	curveFilename         = "txs_curve"
+)
+
+// fieldSelectors gom các flag --field lặp lại.
+type fieldSelectors []txswitness.FieldSpec
+
+func (f *fieldSelectors) String() string {
+	return fmt.Sprint(*f)
+}
+
+func (f *fieldSelectors) Set(value string) error {
+	spec, err := parseFieldSelector(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, spec)
+	return nil
+}
+
+// runProveTx: prove-tx đọc tx, dựng assignment theo các --field, lấy CS/PK của
+// --backend trên --curve từ store (setup nếu shape mới) và ghi proof, public
+// witness và shape vào --out, kèm bundle JSON (txscircuit.ProofBundle, chỉ có cho
+// Groth16 BN254) để gửi cho service khác. Proof PLONK trên BLS12-377 là đầu vào
+// của aggregate.
+func runProveTx(args []string) error {
+	fs := flag.NewFlagSet("prove-tx", flag.ContinueOnError)
+	input := addTxInputFlags(fs)
+	storeDir := fs.String("store", txscircuit.DefaultStoreDir, "artifact store directory")
+	outDir := fs.String("out", "proof", "output directory for proof and public witness")
+	backendName := fs.String("backend", string(txscircuit.BackendGroth16), "proof system: groth16 or plonk")
+	curveName := fs.String("curve", ecc.BN254.String(), "curve: bn254, or bls12-377 (with --backend plonk) for proofs to aggregate")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go run . prove-tx (--tx <hex|base64> | --tx-file <path>) --field <msgIndex>:<path> ...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	backend, err := txscircuit.ParseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	curve, err := parseCurve(*curveName)
+	if err != nil {
+		return err
+	}
+	if curve == txscircuit.InnerCurve && backend != txscircuit.BackendPlonk {
+		return fmt.Errorf("--curve %s needs --backend %s: aggregate only verifies PLONK proofs", curve, txscircuit.BackendPlonk)
+	}
+
+	circuit, assignment, err := input.build()
+	if err != nil {
+		return err
+	}
+	shapeKey := circuit.ShapeKey()
+	fullWitness, err := frontend.NewWitness(assignment, curve.ScalarField())
+	if err != nil {
+		return fmt.Errorf("full witness: %w", err)
+	}
+	proof, err := proveWithStore(txscircuit.NewArtifactStoreForCurve(*storeDir, curve), backend, circuit, fullWitness)
+	if err != nil {
+		return err
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return fmt.Errorf("public witness: %w", err)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return fmt.Errorf("output dir: %w", err)
+	}
+	if err := txscircuit.WriteToFile(filepath.Join(*outDir, proofFilename), proof); err != nil {
+		return fmt.Errorf("write proof: %w", err)
+	}
+	if err := txscircuit.WriteToFile(filepath.Join(*outDir, publicWitnessFilename), publicWitness); err != nil {
+		return fmt.Errorf("write public witness: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, shapeKeyFilename), []byte(shapeKey+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write shape key: %w", err)
+	}
+	shapeJSON, err := json.Marshal(circuit.Shape())
+	if err != nil {
+		return fmt.Errorf("marshal shape: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, shapeFilename), append(shapeJSON, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write shape: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, backendFilename), []byte(string(backend)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write backend: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, curveFilename), []byte(curve.String()+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write curve: %w", err)
+	}
+	bundlePath := filepath.Join(*outDir, bundleFilename)
+	if groth16Proof, ok := proof.(groth16.Proof); ok && curve == ecc.BN254 {
+		bundle, err := txscircuit.NewProofBundle(groth16Proof, assignment)
+		if err != nil {
+			return fmt.Errorf("proof bundle: %w", err)
+		}
+		if err := txscircuit.WriteToFile(bundlePath, bundle); err != nil {
+			return fmt.Errorf("write proof bundle: %w", err)
+		}
+	} else if err := os.Remove(bundlePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		// Bundle cũ của một proof Groth16 trước đó không còn khớp proof mới.
+		return fmt.Errorf("remove stale proof bundle: %w", err)
+	}
+
+	fmt.Printf("Proof written to %s\n", *outDir)
+	return nil
+}
+
+// txInputFlags là các flag chọn tx và field dùng chung cho prove-tx và
+// compare-backends.
+type txInputFlags struct {
+	tx, txFile, format *string
+	maxValueLen        *int
+	maxTxLen           *int
+	maxTypeURLLen      *int
+	fields             fieldSelectors
+}
+
+func addTxInputFlags(fs *flag.FlagSet) *txInputFlags {
+	input := &txInputFlags{
+		tx:            fs.String("tx", "", "tx bytes as hex or base64 (the Tx (hex)/(base64) output of txcodec.Encode)"),
+		txFile:        fs.String("tx-file", "", "file containing the tx as raw bytes, hex or base64"),
+		format:        fs.String("format", "auto", "tx encoding: auto, hex, base64 or raw (raw only with --tx-file)"),
+		maxValueLen:   fs.Int("max-value-len", 0, "pad length-delimited field values to this many bytes (0 = exact length)"),
+		maxTxLen:      fs.Int("max-tx-len", 0, "build a circuit for txs up to this many bytes so txs of different lengths share one shape (0 = exact length)"),
+		maxTypeURLLen: fs.Int("max-type-url-len", 0, "type URL capacity of every message (0 = exact length)"),
+	}
+	fs.Var(&input.fields, "field", "field selector <msgIndex>:<path>, e.g. 0:to_address, 0:amount[1].denom or 1:3[1].1; repeat once per message")
+	return input
+}
+
+// build đọc tx rồi dựng circuit và assignment theo các --field.
+func (input *txInputFlags) build() (*txscircuit.TxsFieldCircuit, *txscircuit.TxsFieldCircuit, error) {
+	txBytes, err := readTxInput(*input.tx, *input.txFile, *input.format)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range input.fields {
+		input.fields[i].MaxValueLen = *input.maxValueLen
+	}
+
+	assignment, configs, err := txswitness.BuildAssignmentWithOptions(txBytes, input.fields, txswitness.Options{
+		Resolver:      txswitness.NewRegistryResolver(newBenchmarkProtoCodec().InterfaceRegistry()),
+		MaxTxLen:      *input.maxTxLen,
+		MaxTypeURLLen: *input.maxTypeURLLen,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("build assignment: %w", err)
+	}
+	if *input.maxTxLen > 0 {
+		return txscircuit.NewTxsFieldCircuitMaxLen(*input.maxTxLen, configs), assignment, nil
+	}
+	return txscircuit.NewTxsFieldCircuit(len(txBytes), configs), assignment, nil
+}
+
+// runVerifyTx: verify-tx kiểm tra proof trong --proof-dir (hoặc bundle JSON
+// --bundle) bằng verifying key trong store (theo shape key, backend và curve đi
+// kèm proof) hoặc --vk, rồi in public input đã decode. --shape-key ghim shape
+// mong đợi thay vì tin shape đi kèm proof.
+func runVerifyTx(args []string) error {
+	fs := flag.NewFlagSet("verify-tx", flag.ContinueOnError)
+	proofDir := fs.String("proof-dir", "proof", "directory written by prove-tx")
+	storeDir := fs.String("store", txscircuit.DefaultStoreDir, "artifact store directory")
+	vkPath := fs.String("vk", "", "verifying key file (default: looked up in --store by shape key); pin --shape-key too, the shape decides how public inputs are read")
+	bundlePath := fs.String("bundle", "", "JSON proof bundle ("+bundleFilename+") to verify instead of --proof-dir")
+	expectedShapeKey := fs.String("shape-key", "", "expected shape key: reject proofs of any other circuit shape")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go run . verify-tx [--proof-dir <dir> | --bundle <file>] [--store <dir> | --vk <file>] [--shape-key <key>]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		bundle  *txscircuit.ProofBundle
+		shape   txscircuit.BundleShape
+		backend = txscircuit.BackendGroth16
+		curve   = ecc.BN254
+		err     error
+	)
+	if *bundlePath != "" {
+		if bundle, err = readProofBundle(*bundlePath); err != nil {
+			return fmt.Errorf("read proof bundle: %w", err)
+		}
+		shape = bundle.Shape
+	} else {
+		if backend, err = readBackend(*proofDir); err != nil {
+			return err
+		}
+		if curve, err = readCurve(*proofDir); err != nil {
+			return err
+		}
+		if shape, err = readShape(filepath.Join(*proofDir, shapeFilename)); err != nil {
+			return fmt.Errorf("read shape: %w", err)
+		}
+	}
+	// Shape key tính lại từ shape (ReadProofBundle đã kiểm tra bundle.ShapeKey
+	// khớp Shape), không tin file shape key trong thư mục proof.
+	shapeKey := shape.ShapeKey()
+	if *expectedShapeKey != "" && shapeKey != *expectedShapeKey {
+		return fmt.Errorf("proof is for shape %s, expected %s", shapeKey, *expectedShapeKey)
+	}
+
+	var (
+		proof         proofObject
+		publicWitness witness.Witness
+	)
+	if bundle != nil {
+		if proof, err = bundle.GetProof(); err != nil {
+			return fmt.Errorf("bundle proof: %w", err)
+		}
+		if publicWitness, err = bundle.PublicWitness(); err != nil {
+			return fmt.Errorf("bundle public witness: %w", err)
+		}
+	} else {
+		if proof, err = readProof(backend, curve, filepath.Join(*proofDir, proofFilename)); err != nil {
+			return fmt.Errorf("read proof: %w", err)
+		}
+		if publicWitness, err = readWitness(curve, filepath.Join(*proofDir, publicWitnessFilename)); err != nil {
+			return fmt.Errorf("read public witness: %w", err)
+		}
+	}
+	public, err := shape.DecodePublicWitness(publicWitness)
+	if err != nil {
+		return fmt.Errorf("decode public witness: %w", err)
+	}
+
+	var vk proofObject
+	if *vkPath != "" {
+		if vk, err = readVerifyingKey(backend, curve, *vkPath); err != nil {
+			return fmt.Errorf("read verifying key: %w", err)
+		}
+	} else if vk, err = loadVerifyingKey(txscircuit.NewArtifactStoreForCurve(*storeDir, curve), backend, shapeKey); err != nil {
+		return err
+	}
+
+	if err := verifyProof(backend, curve, proof, vk, publicWitness); err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	fmt.Println("✅ Proof verification SUCCEEDED!")
+	return printPublicInputs(os.Stdout, shapeKey, shape, public)
+}
+
+// printPublicInputs in những gì proof đã chứng minh: tx và giá trị field của
+// từng msg.
+func printPublicInputs(w io.Writer, shapeKey string, shape txscircuit.BundleShape, public txscircuit.BundlePublic) error {
+	tx, err := hex.DecodeString(public.TxHex)
+	if err != nil {
+		return fmt.Errorf("tx_hex: %w", err)
+	}
+	hash := sha256.Sum256(tx)
+	fmt.Fprintf(w, "Shape key: %s\n", shapeKey)
+	fmt.Fprintf(w, "Tx: %d bytes, sha256 %X\n", public.TxLen, hash)
+	fmt.Fprintf(w, "   %s\n", public.TxHex)
+	for i, msg := range public.Msgs {
+		cfg := shape.Msgs[i]
+		var value string
+		switch {
+		case cfg.VarintField:
+			value = strconv.FormatUint(msg.Varint, 10)
+		case msg.ValueUTF8 != "":
+			value = strconv.Quote(msg.ValueUTF8)
+		default:
+			value = "0x" + msg.ValueHex
+		}
+		label := fmt.Sprintf("Msg %d", msg.MsgIndex)
+		if msg.TypeURL != "" {
+			label += " " + msg.TypeURL
+		}
+		fmt.Fprintf(w, "%s: field %s = %s\n", label, formatFieldPath(msg, cfg), value)
+	}
+	return nil
+}
+
+// formatFieldPath viết đường dẫn field của msg dạng field number như
+// --field, vd. "3[1].2".
+func formatFieldPath(msg txscircuit.BundleMsg, cfg txscircuit.MsgConfig) string {
+	var parts []string
+	for d, key := range msg.PathKeys {
+		part := strconv.Itoa(key >> 3)
+		if d < len(cfg.RepeatedPath) && cfg.RepeatedPath[d] {
+			part += fmt.Sprintf("[%d]", msg.PathIndices[d])
+		}
+		parts = append(parts, part)
+	}
+	part := strconv.Itoa(msg.FieldNumber)
+	if cfg.RepeatedField {
+		part += fmt.Sprintf("[%d]", msg.FieldIndex)
+	}
+	return strings.Join(append(parts, part), ".")
+}
+
+// parseFieldSelector đọc "<msgIndex>:<path>". Path toàn số (vd. "3[1].1") là
+// field number, "[i]" chọn phần tử của field repeated; ngược lại là tên field
+// trong .proto (vd. "amount[1].denom").
+func parseFieldSelector(s string) (txswitness.FieldSpec, error) {
+	index, path, ok := strings.Cut(s, ":")
+	if !ok || path == "" {
+		return txswitness.FieldSpec{}, fmt.Errorf("field selector %q: want <msgIndex>:<path>", s)
+	}
+	msgIndex, err := strconv.Atoi(index)
+	if err != nil || msgIndex < 0 {
+		return txswitness.FieldSpec{}, fmt.Errorf("field selector %q: invalid message index", s)
+	}
+
+	spec := txswitness.FieldSpec{MsgIndex: msgIndex}
+	parts := strings.Split(path, ".")
+	indices := make([]int, 0, len(parts))
+	repeated := false
+	for _, part := range parts {
+		numberPart, indexPart, hasIndex := strings.Cut(part, "[")
+		number, err := strconv.Atoi(numberPart)
+		if err != nil {
+			break
+		}
+		index := -1
+		if hasIndex {
+			index, err = strconv.Atoi(strings.TrimSuffix(indexPart, "]"))
+			if err != nil || index < 0 || !strings.HasSuffix(indexPart, "]") {
+				return txswitness.FieldSpec{}, fmt.Errorf("field selector %q: invalid element index", s)
+			}
+			repeated = true
+		}
+		spec.FieldNumbers = append(spec.FieldNumbers, number)
+		indices = append(indices, index)
+	}
+	if repeated {
+		spec.Indices = indices
+	}
+	switch len(spec.FieldNumbers) {
+	case len(parts):
+	case 0:
+		spec.Path = path
+	default:
+		return txswitness.FieldSpec{}, fmt.Errorf("field selector %q: mixes field numbers and names", s)
+	}
+	return spec, nil
+}
+
+// readTxInput lấy tx từ --tx hoặc --tx-file theo format.
+func readTxInput(txArg, txFile, format string) ([]byte, error) {
+	switch {
+	case txArg != "" && txFile != "":
+		return nil, errors.New("use either --tx or --tx-file")
+	case txArg != "":
+		if format == "raw" {
+			return nil, errors.New("--format raw needs --tx-file")
+		}
+		return decodeTxString(txArg, format)
+	case txFile != "":
+		data, err := os.ReadFile(txFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tx file: %w", err)
+		}
+		// Tx nhị phân luôn có byte không in được (tag 0x0a, độ dài, ...).
+		if format == "raw" || (format == "auto" && !isPrintable(data)) {
+			return data, nil
+		}
+		return decodeTxString(string(data), format)
+	default:
+		return nil, errors.New("missing --tx or --tx-file")
+	}
+}
+
+func decodeTxString(s, format string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	switch format {
+	case "hex":
+		return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	case "base64":
+		return base64.StdEncoding.DecodeString(s)
+	case "auto":
+		if data, err := hex.DecodeString(strings.TrimPrefix(s, "0x")); err == nil {
+			return data, nil
+		}
+		if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return data, nil
+		}
+		return nil, errors.New("tx is neither hex nor base64")
+	default:
+		return nil, fmt.Errorf("unknown tx format %q", format)
+	}
+}
+
+func isPrintable(data []byte) bool {
+	for _, b := range data {
+		if (b < 0x20 || b > 0x7e) && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func readProofBundle(path string) (*txscircuit.ProofBundle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return txscircuit.ReadProofBundle(file)
+}
+
+func readShape(path string) (txscircuit.BundleShape, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return txscircuit.BundleShape{}, err
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	var shape txscircuit.BundleShape
+	if err := dec.Decode(&shape); err != nil {
+		return txscircuit.BundleShape{}, err
+	}
+	return shape, nil
+}
+
+func readWitness(curve ecc.ID, path string) (witness.Witness, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	w, err := witness.New(curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+	txswitness "github.com/DongLieu/msg-circuit/txscircuit/witness"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+func TestParseFieldSelector(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    txswitness.FieldSpec
+		wantErr bool
+	}{
+		{in: "0:amount[0]", want: txswitness.FieldSpec{MsgIndex: 0, Path: "amount[0]"}},
+		{in: "1:amount.denom", want: txswitness.FieldSpec{MsgIndex: 1, Path: "amount.denom"}},
+		{in: "2:3.1", want: txswitness.FieldSpec{MsgIndex: 2, FieldNumbers: []int{3, 1}}},
+		{in: "2:3[1].1", want: txswitness.FieldSpec{MsgIndex: 2, FieldNumbers: []int{3, 1}, Indices: []int{1, -1}}},
+		{in: "2:3[x].1", wantErr: true},
+		{in: "0:3.denom", wantErr: true},
+		{in: "amount", wantErr: true},
+		{in: "-1:amount", wantErr: true},
+		{in: "0:", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFieldSelector(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReadTxInput(t *testing.T) {
+	tx := []byte{0x0a, 0x02, 0x0a, 0x00, 0x12, 0x00}
+	dir := t.TempDir()
+	rawPath := filepath.Join(dir, "tx.bin")
+	hexPath := filepath.Join(dir, "tx.hex")
+	if err := os.WriteFile(rawPath, tx, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hexPath, []byte("0a020a001200\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		txArg, txFile string
+		format        string
+	}{
+		{"hex", "0a020a001200", "", "auto"},
+		{"0x hex", "0x0a020a001200", "", "hex"},
+		{"base64", "CgIKABIA", "", "auto"},
+		{"raw file", "", rawPath, "auto"},
+		{"hex file", "", hexPath, "auto"},
+	}
+	for _, tt := range tests {
+		got, err := readTxInput(tt.txArg, tt.txFile, tt.format)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(got, tx) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, tx)
+		}
+	}
+
+	if _, err := readTxInput("not a tx!", "", "auto"); err == nil {
+		t.Error("accepted input that is neither hex nor base64")
+	}
+}
+
+func TestReadBackend(t *testing.T) {
+	dir := t.TempDir()
+	// Thư mục proof ghi trước khi có --backend là Groth16.
+	if backend, err := readBackend(dir); err != nil || backend != txscircuit.BackendGroth16 {
+		t.Fatalf("missing file: backend = %q, err = %v", backend, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, backendFilename), []byte("plonk\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if backend, err := readBackend(dir); err != nil || backend != txscircuit.BackendPlonk {
+		t.Fatalf("plonk: backend = %q, err = %v", backend, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, backendFilename), []byte("stark\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBackend(dir); err == nil {
+		t.Fatal("accepted an unknown backend")
+	}
+}
+
+func TestReadCurve(t *testing.T) {
+	dir := t.TempDir()
+	if curve, err := readCurve(dir); err != nil || curve != ecc.BN254 {
+		t.Fatalf("missing file: curve = %v, err = %v", curve, err)
+	}
+
+	for _, name := range []string{"bls12_377", "bls12-377", "BLS12-377"} {
+		if err := os.WriteFile(filepath.Join(dir, curveFilename), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if curve, err := readCurve(dir); err != nil || curve != txscircuit.InnerCurve {
+			t.Fatalf("%s: curve = %v, err = %v", name, curve, err)
+		}
+	}
+
+	// BW6-761 là curve của proof gộp, prove-tx không prove trên nó.
+	if _, err := parseCurve("bw6_761"); err == nil {
+		t.Fatal("accepted an unsupported curve")
+	}
+}
+
+func TestVerifyTxShapeKeyPin(t *testing.T) {
+	dir := t.TempDir()
+	shape := txscircuit.BundleShape{TxCapacity: 64, Msgs: []txscircuit.MsgConfig{{FieldValueLen: 8}}}
+	data, err := json.Marshal(shape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, shapeFilename), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shape của proof phải khớp --shape-key trước khi đọc gì khác.
+	err = runVerifyTx([]string{"--proof-dir", dir, "--shape-key", strings.Repeat("0", 64)})
+	if err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("err = %v, want shape key mismatch", err)
+	}
+	// Shape khớp: dừng ở bước đọc proof (thư mục không có proof).
+	err = runVerifyTx([]string{"--proof-dir", dir, "--shape-key", shape.ShapeKey()})
+	if err == nil || !strings.Contains(err.Error(), "read proof") {
+		t.Fatalf("err = %v, want missing proof", err)
+	}
+}
+
+func TestPrintPublicInputs(t *testing.T) {
+	shape := txscircuit.BundleShape{Msgs: []txscircuit.MsgConfig{
+		{PathDepth: 1, RepeatedPath: []bool{true}},
+		{VarintField: true},
+	}}
+	public := txscircuit.BundlePublic{
+		TxHex: "0a00",
+		TxLen: 2,
+		Msgs: []txscircuit.BundleMsg{
+			{MsgIndex: 0, TypeURL: "/cosmos.bank.v1beta1.MsgSend", PathKeys: []int{0x1a}, PathIndices: []int{1}, FieldKey: 0x0a, FieldNumber: 1, ValueHex: "7561746f6d", ValueUTF8: "uatom"},
+			{MsgIndex: 1, FieldKey: 0x18, FieldNumber: 3, Varint: 1234},
+		},
+	}
+	var out bytes.Buffer
+	if err := printPublicInputs(&out, "abc", shape, public); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Shape key: abc",
+		"Tx: 2 bytes, sha256 ",
+		`Msg 0 /cosmos.bank.v1beta1.MsgSend: field 3[1].1 = "uatom"`,
+		"Msg 1: field 3 = 1234",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package txcodec
+
+import (
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ExecuteContractTypeURL là type URL của cosmwasm.wasm.v1.MsgExecuteContract.
+const ExecuteContractTypeURL = "/cosmwasm.wasm.v1.MsgExecuteContract"
+
+// MsgExecuteContract là bản dựng tay của cosmwasm.wasm.v1.MsgExecuteContract
+// (repo không phụ thuộc wasmd), đủ để ProtoCodec.UnpackAny decode message sau
+// khi RegisterExecuteContract.
+type MsgExecuteContract struct {
+	Sender   string    // 1
+	Contract string    // 2
+	Msg      []byte    // 3, JSON
+	Funds    sdk.Coins // 5
+}
+
+func (m *MsgExecuteContract) Reset()         { *m = MsgExecuteContract{} }
+func (m *MsgExecuteContract) String() string { return fmt.Sprintf("%+v", *m) }
+func (*MsgExecuteContract) ProtoMessage()    {}
+
+// XXX_MessageName cho gogoproto biết tên message (type URL) mà không cần file
+// descriptor.
+func (*MsgExecuteContract) XXX_MessageName() string {
+	return "cosmwasm.wasm.v1.MsgExecuteContract"
+}
+
+// Marshal encode message theo thứ tự field như gogoproto.
+func (m *MsgExecuteContract) Marshal() ([]byte, error) {
+	var buf []byte
+	if m.Sender != "" {
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, m.Sender)
+	}
+	if m.Contract != "" {
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendString(buf, m.Contract)
+	}
+	if len(m.Msg) > 0 {
+		buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, m.Msg)
+	}
+	for i := range m.Funds {
+		coin, err := m.Funds[i].Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("marshal coin: %w", err)
+		}
+		buf = protowire.AppendTag(buf, 5, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, coin)
+	}
+	return buf, nil
+}
+
+// Unmarshal decode giống code gogoproto sinh ra: field sau ghi đè field
+// trước, funds được nối thêm, field lạ bị bỏ qua và field đã biết sai wire
+// type là lỗi.
+func (m *MsgExecuteContract) Unmarshal(data []byte) error {
+	m.Reset()
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		switch num {
+		case 1, 2, 3, 5:
+			if typ != protowire.BytesType {
+				return fmt.Errorf("MsgExecuteContract: wrong wireType = %d for field %d", typ, num)
+			}
+		}
+
+		var value []byte
+		if typ == protowire.BytesType {
+			value, n = protowire.ConsumeBytes(data)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch num {
+		case 1:
+			m.Sender = string(value)
+		case 2:
+			m.Contract = string(value)
+		case 3:
+			m.Msg = append([]byte(nil), value...)
+		case 5:
+			var coin sdk.Coin
+			if err := coin.Unmarshal(value); err != nil {
+				return fmt.Errorf("MsgExecuteContract.funds: %w", err)
+			}
+			m.Funds = append(m.Funds, coin)
+		}
+	}
+	return nil
+}
+
+// RegisterExecuteContract đăng ký MsgExecuteContract là sdk.Msg trong
+// registry.
+func RegisterExecuteContract(registry codectypes.InterfaceRegistry) {
+	registry.RegisterImplementations((*sdk.Msg)(nil), &MsgExecuteContract{})
+}
+
+// BuildExecuteContractAny dựng Any của MsgExecuteContract với payload
+// execMsg (thường rất lớn, vd. case3).
+func BuildExecuteContractAny(sender, contract string, execMsg []byte, funds sdk.Coins) (*codectypes.Any, error) {
+	msg := &MsgExecuteContract{Sender: sender, Contract: contract, Msg: execMsg, Funds: funds}
+	value, err := msg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &codectypes.Any{TypeUrl: ExecuteContractTypeURL, Value: value}, nil
+}
@@ -0,0 +1,208 @@
+package txscircuit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	mimc_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	plonkbls12377 "github.com/consensys/gnark/backend/plonk/bls12-377"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/commitments/kzg"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/emulated"
+	stdplonk "github.com/consensys/gnark/std/recursion/plonk"
+)
+
+// AggregateCircuit dùng 2-chain BLS12-377/BW6-761: proof TxsFieldCircuit được
+// prove bằng PLONK trên BLS12-377 (InnerCurve) và AggregateCircuit compile
+// trên BW6-761 (AggregateCurve). Scalar field của BW6-761 là base field của
+// BLS12-377 nên phép toán trên curve và pairing của verifier chạy native trong
+// circuit, không cần emulate field.
+//
+// Proof con vì vậy không phải BN254: BN254 không có 2-chain, verify proof BN254
+// của prove-tx mặc định trong circuit phải emulate cả BN254, nên tx cần được
+// prove lại trên InnerCurve. Proof gộp cũng nằm trên BW6-761 chứ không phải
+// BN254. Proof con là PLONK chứ không phải Groth16 vì
+// mỗi byte của PublicTxBytes là một public input: verifier Groth16 tốn một
+// phép nhân vô hướng cho mỗi public input (hơn 1.6M constraint cho một tx
+// ~150 byte), còn PLONK chỉ tốn vài phép nhân trong scalar field và
+// AssertSameProofs gộp mọi KZG opening vào một lần kiểm tra pairing.
+const (
+	InnerCurve     = ecc.BLS12_377
+	AggregateCurve = ecc.BW6_761
+)
+
+type (
+	innerG1     = sw_bls12377.G1Affine
+	innerG2     = sw_bls12377.G2Affine
+	innerGT     = sw_bls12377.GT
+	innerScalar = sw_bls12377.ScalarField
+)
+
+// InnerProverOption phải được truyền cho plonk.Prove khi prove
+// TxsFieldCircuit trên InnerCurve: transcript Fiat-Shamir, KZG folding và
+// commitment của logderivlookup khi đó dùng hash tính được trong
+// AggregateCircuit thay cho SHA-256.
+func InnerProverOption() backend.ProverOption {
+	return stdplonk.GetNativeProverOptions(AggregateCurve.ScalarField(), InnerCurve.ScalarField())
+}
+
+// InnerVerifierOption là option của plonk.Verify tương ứng InnerProverOption.
+func InnerVerifierOption() backend.VerifierOption {
+	return stdplonk.GetNativeVerifierOptions(AggregateCurve.ScalarField(), InnerCurve.ScalarField())
+}
+
+// AggregateCircuit verify len(Proofs) proof PLONK của cùng một shape
+// TxsFieldCircuit (cùng inner verifying key, nhúng vào circuit như hằng số).
+// Public input duy nhất là PublicHash = MiMC-BW6-761 của mọi public input của
+// các proof con, lần lượt theo thứ tự proof (xem AggregateHash).
+type AggregateCircuit struct {
+	PublicHash frontend.Variable `gnark:",public"`
+	Proofs     []stdplonk.Proof[innerScalar, innerG1, innerG2]
+	Witnesses  []stdplonk.Witness[innerScalar]
+
+	vk       stdplonk.VerifyingKey[innerScalar, innerG1, innerG2] `gnark:"-"`
+	vkDigest string                                               `gnark:"-"`
+}
+
+// NewAggregateCircuit dựng AggregateCircuit gộp n proof được verify bằng
+// innerVK (verifying key PLONK BLS12-377 của shape TxsFieldCircuit).
+func NewAggregateCircuit(innerVK plonk.VerifyingKey, n int) (*AggregateCircuit, error) {
+	if n < 1 {
+		return nil, errors.New("txscircuit: aggregate needs at least one proof")
+	}
+	vk, ok := innerVK.(*plonkbls12377.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("txscircuit: aggregate needs a %s plonk verifying key, got %T", InnerCurve, innerVK)
+	}
+	circuitVK, err := stdplonk.ValueOfVerifyingKey[innerScalar, innerG1, innerG2](innerVK)
+	if err != nil {
+		return nil, fmt.Errorf("inner verifying key: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("inner verifying key: %w", err)
+	}
+	digest := sha256.Sum256(buf.Bytes())
+
+	// Như stdplonk.PlaceholderProof/PlaceholderWitness nhưng lấy kích thước
+	// từ verifying key, nên phía aggregate không cần constraint system con.
+	nbCommitments := len(vk.Qcp)
+	circuit := &AggregateCircuit{
+		Proofs:    make([]stdplonk.Proof[innerScalar, innerG1, innerG2], n),
+		Witnesses: make([]stdplonk.Witness[innerScalar], n),
+		vk:        circuitVK,
+		vkDigest:  hex.EncodeToString(digest[:]),
+	}
+	for i := range n {
+		circuit.Proofs[i].BatchedProof.ClaimedValues = make([]emulated.Element[innerScalar], 6+nbCommitments)
+		circuit.Proofs[i].Bsb22Commitments = make([]kzg.Commitment[innerG1], nbCommitments)
+		circuit.Witnesses[i].Public = make([]emulated.Element[innerScalar], vk.NbPublicVariables)
+	}
+	return circuit, nil
+}
+
+// NewAggregateAssignment dựng assignment từ các proof con (prove với
+// InnerProverOption) và public witness tương ứng.
+func NewAggregateAssignment(proofs []plonk.Proof, publicWitnesses []witness.Witness) (*AggregateCircuit, error) {
+	if len(proofs) != len(publicWitnesses) {
+		return nil, fmt.Errorf("txscircuit: %d proofs but %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+	publicHash, err := AggregateHash(publicWitnesses)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &AggregateCircuit{
+		PublicHash: publicHash,
+		Proofs:     make([]stdplonk.Proof[innerScalar, innerG1, innerG2], len(proofs)),
+		Witnesses:  make([]stdplonk.Witness[innerScalar], len(proofs)),
+	}
+	for i := range proofs {
+		if assignment.Proofs[i], err = stdplonk.ValueOfProof[innerScalar, innerG1, innerG2](proofs[i]); err != nil {
+			return nil, fmt.Errorf("proof %d: %w", i, err)
+		}
+		if assignment.Witnesses[i], err = stdplonk.ValueOfWitness[innerScalar](publicWitnesses[i]); err != nil {
+			return nil, fmt.Errorf("public witness %d: %w", i, err)
+		}
+	}
+	return assignment, nil
+}
+
+// AggregateHash tính PublicHash ngoài circuit: MiMC trên scalar field BW6-761
+// của mọi public input (phần tử scalar field BLS12-377) của từng proof, theo
+// thứ tự. Verifier của proof gộp tính lại giá trị này từ các public witness.
+func AggregateHash(publicWitnesses []witness.Witness) (*big.Int, error) {
+	h := mimc_bw6761.NewMiMC()
+	for i, w := range publicWitnesses {
+		vector, ok := w.Vector().(fr_bls12377.Vector)
+		if !ok {
+			return nil, fmt.Errorf("txscircuit: public witness %d is %T, want a %s witness", i, w.Vector(), InnerCurve)
+		}
+		for j := range vector {
+			var value big.Int
+			var element fr_bw6761.Element
+			element.SetBigInt(vector[j].BigInt(&value))
+			b := element.Bytes()
+			h.Write(b[:])
+		}
+	}
+	return new(big.Int).SetBytes(h.Sum(nil)), nil
+}
+
+func (circuit *AggregateCircuit) Define(api frontend.API) error {
+	verifier, err := stdplonk.NewVerifier[innerScalar, innerG1, innerG2, innerGT](api)
+	if err != nil {
+		return fmt.Errorf("new verifier: %w", err)
+	}
+	if err := verifier.AssertSameProofs(circuit.vk, circuit.Proofs, circuit.Witnesses); err != nil {
+		return err
+	}
+	scalars, err := emulated.NewField[innerScalar](api)
+	if err != nil {
+		return err
+	}
+	hasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+
+	// Bits chuẩn (< modulus BLS12-377) để mỗi public input có đúng một giá
+	// trị native trong hash.
+	for i := range circuit.Witnesses {
+		for j := range circuit.Witnesses[i].Public {
+			hasher.Write(api.FromBinary(scalars.ToBitsCanonical(&circuit.Witnesses[i].Public[j])...))
+		}
+	}
+	api.AssertIsEqual(hasher.Sum(), circuit.PublicHash)
+	return nil
+}
+
+// aggregateShape là mọi tham số compile-time của AggregateCircuit.
+type aggregateShape struct {
+	Version  int
+	InnerVK  string
+	NbProofs int
+}
+
+// ShapeKey trả về hash của (inner verifying key, số proof), dùng làm key của
+// AggregateCircuit trong ArtifactStore của AggregateCurve.
+func (circuit *AggregateCircuit) ShapeKey() string {
+	data, err := json.Marshal(aggregateShape{Version: shapeVersion, InnerVK: circuit.vkDigest, NbProofs: len(circuit.Proofs)})
+	if err != nil {
+		panic(fmt.Errorf("marshal aggregate shape: %w", err))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
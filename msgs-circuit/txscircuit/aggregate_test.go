@@ -0,0 +1,172 @@
+package txscircuit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+// sendProofs prove MsgSend.amount của hai tx cùng shape bằng PLONK trên
+// InnerCurve.
+func sendProofs(t *testing.T) (plonk.VerifyingKey, []plonk.Proof, []witness.Witness) {
+	t.Helper()
+	var txs [][]byte
+	var assertions []testAssertion
+	for _, amount := range []string{"4242", "9999"} {
+		sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", amount))
+		txs = append(txs, buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue)))
+		assertions = append(assertions, lastFieldAssertion(t, sendValue, 0x1a))
+	}
+	return innerProofs(t, txs, assertions)
+}
+
+// innerProofs prove assertions[i] trên txs[i] (cùng shape) bằng PLONK trên
+// InnerCurve.
+func innerProofs(t *testing.T, txs [][]byte, assertions []testAssertion) (plonk.VerifyingKey, []plonk.Proof, []witness.Witness) {
+	t.Helper()
+	var (
+		artifacts       *PlonkArtifacts
+		proofs          []plonk.Proof
+		publicWitnesses []witness.Witness
+	)
+	for i, tx := range txs {
+		circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertions[i]})
+		if artifacts == nil {
+			var err error
+			if artifacts, _, err = NewArtifactStoreForCurve(t.TempDir(), InnerCurve).LoadOrSetupPlonk(circuit); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fullWitness, err := frontend.NewWitness(assignment, InnerCurve.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := plonk.Prove(artifacts.CS, artifacts.PK, fullWitness, InnerProverOption())
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicWitness, err := fullWitness.Public()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := plonk.Verify(proof, artifacts.VK, publicWitness, InnerVerifierOption()); err != nil {
+			t.Fatal(err)
+		}
+		proofs = append(proofs, proof)
+		publicWitnesses = append(publicWitnesses, publicWitness)
+	}
+	return artifacts.VK, proofs, publicWitnesses
+}
+
+// TestAggregateCircuit gộp hai proof: assignment đúng phải solve được, sai
+// PublicHash hoặc đổi public input của một proof con thì không.
+func TestAggregateCircuit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("inner setup and aggregate solving are slow")
+	}
+
+	vk, proofs, publicWitnesses := sendProofs(t)
+	circuit, err := NewAggregateCircuit(vk, len(proofs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := frontend.Compile(AggregateCurve.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("aggregate of %d proofs: %d constraints", len(proofs), ccs.GetNbConstraints())
+
+	assignment, err := NewAggregateAssignment(proofs, publicWitnesses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, AggregateCurve.ScalarField()); err != nil {
+		t.Fatalf("valid aggregate: %v", err)
+	}
+
+	wrongHash := *assignment
+	wrongHash.PublicHash = new(big.Int).Add(assignment.PublicHash.(*big.Int), big.NewInt(1))
+	if err := test.IsSolved(circuit, &wrongHash, AggregateCurve.ScalarField()); err == nil {
+		t.Error("accepted a wrong public hash")
+	}
+
+	// Proof của tx 0 với public input của tx 1 (hash tính lại cho khớp).
+	swapped, err := NewAggregateAssignment([]plonk.Proof{proofs[0], proofs[0]}, publicWitnesses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, swapped, AggregateCurve.ScalarField()); err == nil {
+		t.Error("accepted a proof with another tx's public inputs")
+	}
+}
+
+// TestAggregateProveVerify chạy thật Groth16 setup, prove và verify trên
+// AggregateCurve cho một proof con của tx 13 byte (TxBody chỉ có một message
+// nhỏ, không AuthInfo). Cấu hình này ≈ 152k constraint BW6-761, đủ nhỏ cho
+// máy 1 vCPU; gộp hai tx thật ở TestAggregateCircuit cần ≈ 2.4M.
+func TestAggregateProveVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("aggregate Groth16 setup is slow")
+	}
+
+	value := appendLengthDelimitedField(nil, 1, []byte("x"))
+	tx := appendLengthDelimitedField(nil, 1, appendLengthDelimitedField(nil, 1, anyBytes("/m", value)))
+	vk, proofs, publicWitnesses := innerProofs(t, [][]byte{tx}, []testAssertion{lastFieldAssertion(t, value, 0x0a)})
+
+	circuit, err := NewAggregateCircuit(vk, len(proofs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts, _, err := NewArtifactStoreForCurve(t.TempDir(), AggregateCurve).LoadOrSetup(circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("aggregate of %d proof: %d constraints", len(proofs), artifacts.CS.GetNbConstraints())
+
+	assignment, err := NewAggregateAssignment(proofs, publicWitnesses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(assignment, AggregateCurve.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(artifacts.CS, artifacts.PK, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, artifacts.VK, publicWitness); err != nil {
+		t.Fatalf("aggregated proof rejected: %v", err)
+	}
+
+	// Verifier tính PublicHash từ public witness con; hash khác thì proof gộp
+	// không còn hợp lệ.
+	wrongHash := &AggregateCircuit{PublicHash: new(big.Int).Add(assignment.PublicHash.(*big.Int), big.NewInt(1))}
+	wrongWitness, err := frontend.NewWitness(wrongHash, AggregateCurve.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, artifacts.VK, wrongWitness); err == nil {
+		t.Error("aggregated proof verified against a wrong public hash")
+	}
+}
+
+func TestNewAggregateCircuitErrors(t *testing.T) {
+	if _, err := NewAggregateCircuit(plonk.NewVerifyingKey(InnerCurve), 0); err == nil {
+		t.Error("accepted zero proofs")
+	}
+	if _, err := NewAggregateCircuit(plonk.NewVerifyingKey(AggregateCurve), 1); err == nil {
+		t.Error("accepted a verifying key on the wrong curve")
+	}
+}
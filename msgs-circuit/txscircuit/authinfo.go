@@ -0,0 +1,155 @@
+package txscircuit
+
+import "github.com/consensys/gnark/frontend"
+
+// CoinPublic là một sdk.Coin trong Fee.amount làm public input. Denom và
+// Amount (chuỗi số thập phân) được pad 0 tới sức chứa trong AuthInfoConfig.
+type CoinPublic struct {
+	Denom     []frontend.Variable `gnark:",public"`
+	DenomLen  frontend.Variable   `gnark:",public"`
+	Amount    []frontend.Variable `gnark:",public"`
+	AmountLen frontend.Variable   `gnark:",public"`
+	// Offset của field coin tính từ đầu payload của Fee.
+	Offset frontend.Variable `gnark:",secret"`
+}
+
+// AuthInfoAssertion chứng minh fee của tx: FeeCoins là toàn bộ Fee.amount theo
+// đúng thứ tự và GasLimit là Fee.gas_limit.
+//
+// AuthInfo phải là field 2 duy nhất của TxRaw, nằm ngay sau body_bytes như
+// khi TxRaw được encode chuẩn, và Fee phải xuất hiện đúng một lần trong
+// AuthInfo: decoder dùng auth_info_bytes cuối cùng và merge các Fee lặp lại
+// (nối Fee.amount). gas_limit lấy theo occurrence cuối cùng và phải có mặt
+// (không chứng minh được gas_limit = 0 bị bỏ qua khi encode).
+type AuthInfoAssertion struct {
+	FeeCoins []CoinPublic
+	GasLimit frontend.Variable `gnark:",public"`
+	// FeeOffset tính từ đầu AuthInfo, GasLimitOffset tính từ đầu payload Fee.
+	FeeOffset      frontend.Variable `gnark:",secret"`
+	GasLimitOffset frontend.Variable `gnark:",secret"`
+}
+
+// AuthInfoConfig định nghĩa kích thước cố định cho AuthInfoAssertion.
+type AuthInfoConfig struct {
+	// NumFeeCoins là số coin chính xác trong Fee.amount.
+	NumFeeCoins  int
+	MaxDenomLen  int
+	MaxAmountLen int
+	// MaxFields giới hạn số field được duyệt trong AuthInfo và trong Fee;
+	// 0 dùng DefaultMaxMsgFields.
+	MaxFields int
+}
+
+func (cfg AuthInfoConfig) maxFields() int {
+	if cfg.MaxFields > 0 {
+		return cfg.MaxFields
+	}
+	return DefaultMaxMsgFields
+}
+
+// WithAuthInfo bật AuthInfoAssertion cho circuit (dùng được với cả
+// NewTxsFieldCircuit và NewTxsFieldCircuitMaxLen).
+func (circuit *TxsFieldCircuit) WithAuthInfo(cfg AuthInfoConfig) *TxsFieldCircuit {
+	coins := make([]CoinPublic, cfg.NumFeeCoins)
+	for i := range coins {
+		coins[i].Denom = make([]frontend.Variable, cfg.MaxDenomLen)
+		coins[i].Amount = make([]frontend.Variable, cfg.MaxAmountLen)
+	}
+	circuit.AuthInfo = []AuthInfoAssertion{{FeeCoins: coins}}
+	circuit.authInfoConfig = cfg
+	return circuit
+}
+
+// verifyAuthInfo parse auth_info_bytes bắt đầu tại authTagIdx (ngay sau
+// body_bytes) và ràng buộc Fee khớp assertion. rawFields là các field của
+// TxRaw.
+func (circuit *TxsFieldCircuit) verifyAuthInfo(
+	api frontend.API,
+	tx *txBytes,
+	assertion AuthInfoAssertion,
+	rawFields []fieldToken,
+	authTagIdx frontend.Variable,
+	txLen frontend.Variable,
+	maxIdx frontend.Variable,
+) {
+	cfg := circuit.authInfoConfig
+
+	// [body][auth_info mồi][auth_info thật][sig] decode ra auth_info thứ hai.
+	assertOnlyOccurrence(api, rawFields, 0x12, 2, authTagIdx)
+	api.AssertIsEqual(selectByteAt(api, tx, authTagIdx, maxIdx), 0x12)
+	authLenIdx := api.Add(authTagIdx, 1)
+	authLen, authLenBytes := decodeVarint4Bytes(api, tx, authLenIdx, maxIdx)
+	authStart := api.Add(authLenIdx, authLenBytes)
+	authEnd := api.Add(authStart, authLen)
+	assertLessOrEqual(api, authEnd, txLen, circuit.txIndexBits+1)
+
+	// AuthInfo.fee = field 2, singular: descendPath từ chối Fee lặp lại.
+	feeStep := PathStep{Key: 0x12, Index: 0, Offset: assertion.FeeOffset}
+	feeStart, feeEnd := circuit.descendPath(api, tx, feeStep, false, authStart, authEnd, cfg.maxFields(), maxIdx)
+	fields := tokenizeFields(api, tx, feeStart, feeEnd, cfg.maxFields(), maxIdx)
+
+	// Fee.gas_limit = field 2, varint
+	api.ToBinary(assertion.GasLimitOffset, circuit.txIndexBits)
+	gasStart := api.Add(feeStart, assertion.GasLimitOffset)
+	assertLastOccurrence(api, fields, 0x10, 0, gasStart)
+	api.AssertIsEqual(selectByteAt(api, tx, gasStart, maxIdx), 0x10)
+	gasLimit, _ := decodeUint64Varint(api, tx, api.Add(gasStart, 1), maxIdx)
+	api.AssertIsEqual(gasLimit, assertion.GasLimit)
+
+	// Fee.amount = field 1 (repeated Coin): số coin trong Fee phải bằng
+	// len(FeeCoins), và các Offset tăng ngặt, mỗi Offset là biên của một coin
+	// => FeeCoins là toàn bộ Fee.amount theo đúng thứ tự.
+	numCoins := frontend.Variable(0)
+	for _, field := range fields {
+		numCoins = api.Add(numCoins, api.Mul(field.Active, api.IsZero(api.Sub(field.Key, 0x0a))))
+	}
+	api.AssertIsEqual(numCoins, len(assertion.FeeCoins))
+
+	for k, coin := range assertion.FeeCoins {
+		api.ToBinary(coin.Offset, circuit.txIndexBits)
+		if k > 0 {
+			prev := assertion.FeeCoins[k-1].Offset
+			assertLessOrEqual(api, api.Add(prev, 1), coin.Offset, circuit.txIndexBits+1)
+		}
+		coinStart := api.Add(feeStart, coin.Offset)
+
+		isBoundary := frontend.Variable(0)
+		for _, field := range fields {
+			isBoundary = api.Add(isBoundary, api.Mul(field.Active, api.IsZero(api.Sub(field.Start, coinStart))))
+		}
+		api.AssertIsEqual(isBoundary, 1)
+		api.AssertIsEqual(selectByteAt(api, tx, coinStart, maxIdx), 0x0a)
+
+		coinLenIdx := api.Add(coinStart, 1)
+		coinLen, coinLenBytes := decodeVarint4Bytes(api, tx, coinLenIdx, maxIdx)
+		coinPayload := api.Add(coinLenIdx, coinLenBytes)
+
+		// Coin được encode chuẩn: denom (field 1) rồi amount (field 2).
+		amountTagIdx := assertPaddedBytesField(api, tx, coinPayload, 0x0a, coin.Denom, coin.DenomLen, maxIdx)
+		coinEnd := assertPaddedBytesField(api, tx, amountTagIdx, 0x12, coin.Amount, coin.AmountLen, maxIdx)
+		api.AssertIsEqual(coinEnd, api.Add(coinPayload, coinLen))
+	}
+}
+
+// assertPaddedBytesField ràng buộc tx[idx] là field length-delimited với key
+// key, payload có độ dài length <= len(value) và bằng value (pad 0). Trả về vị
+// trí ngay sau field.
+func assertPaddedBytesField(
+	api frontend.API,
+	tx *txBytes,
+	idx frontend.Variable,
+	key int,
+	value []frontend.Variable,
+	length frontend.Variable,
+	maxIdx frontend.Variable,
+) frontend.Variable {
+	api.AssertIsEqual(selectByteAt(api, tx, idx, maxIdx), key)
+
+	lenIdx := api.Add(idx, 1)
+	fieldLen, lenBytes := decodeVarint4Bytes(api, tx, lenIdx, maxIdx)
+	api.AssertIsEqual(fieldLen, length)
+
+	start := api.Add(lenIdx, lenBytes)
+	assertPaddedValue(api, tx, start, value, length, maxIdx)
+	return api.Add(start, length)
+}
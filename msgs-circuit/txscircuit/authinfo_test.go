@@ -0,0 +1,150 @@
+package txscircuit
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type testCoin struct {
+	Denom  string
+	Amount string
+}
+
+func TestTxsFieldCircuitAuthInfo(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	msg := anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue)
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+	coins := []testCoin{{"uatom", "5000"}, {"ibc/27394FB092D2ECCD56123C74F36E4C1F", "12"}}
+	tx := buildTestTxWithFee("", testFee(1<<40, coinBytes(coins[0].Denom, coins[0].Amount), coinBytes(coins[1].Denom, coins[1].Amount)), msg)
+	cfg := AuthInfoConfig{NumFeeCoins: 2, MaxDenomLen: 64, MaxAmountLen: 16}
+
+	circuit, assignment := buildAuthInfoCircuit(t, tx, assertions, cfg, coins, 1<<40)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("valid fee rejected: %v", err)
+	}
+
+	circuit, assignment = buildAuthInfoCircuit(t, tx, assertions, cfg, coins, 1<<40+1)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted wrong gas limit")
+	}
+
+	wrongAmount := []testCoin{coins[0], {coins[1].Denom, "13"}}
+	circuit, assignment = buildAuthInfoCircuit(t, tx, assertions, cfg, wrongAmount, 1<<40)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted wrong fee amount")
+	}
+
+	// Bỏ sót một coin của Fee.amount.
+	cfg.NumFeeCoins = 1
+	circuit, assignment = buildAuthInfoCircuit(t, tx, assertions, cfg, coins[:1], 1<<40)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a partial fee")
+	}
+}
+
+func TestTxsFieldCircuitAuthInfoMaxLen(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+	cfg := AuthInfoConfig{NumFeeCoins: 1, MaxDenomLen: 16, MaxAmountLen: 16}
+
+	fixedCircuit, fixedAssignment := buildAuthInfoCircuit(t, tx, assertions, cfg, []testCoin{{"uatom", "5000"}}, 300000)
+	_, maxLenAssignment := buildTestCircuitMaxLen(t, 512, tx, assertions)
+	maxLenAssignment.WithAuthInfo(cfg)
+	maxLenAssignment.AuthInfo = fixedAssignment.AuthInfo
+	circuit := NewTxsFieldCircuitMaxLen(512, fixedCircuit.msgConfigs).WithAuthInfo(cfg)
+	if err := test.IsSolved(circuit, maxLenAssignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("valid fee rejected in MaxTxLen mode: %v", err)
+	}
+}
+
+// TestTxsFieldCircuitAuthInfoDuplicates: decoder dùng auth_info_bytes cuối
+// cùng và merge các Fee lặp lại, nên cả hai bố cục đều phải bị từ chối.
+func TestTxsFieldCircuitAuthInfoDuplicates(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	msg := anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue)
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+	cfg := AuthInfoConfig{NumFeeCoins: 1, MaxDenomLen: 16, MaxAmountLen: 16}
+	cheap := []testCoin{{"uatom", "1"}}
+
+	tx := buildTestTxWithFee("", testFee(200000, coinBytes("uatom", "1000000")), msg)
+	_, bodyEnd := readLengthDelimited(t, tx, 1)
+	withAuthInfo := func(authInfo []byte) []byte {
+		out := appendLengthDelimitedField(append([]byte(nil), tx[:bodyEnd]...), 2, authInfo)
+		return appendLengthDelimitedField(out, 3, make([]byte, 64))
+	}
+
+	// [body][auth_info mồi][auth_info thật][sig]: chứng minh auth_info mồi.
+	decoy := appendLengthDelimitedField(nil, 2, testFee(200000, coinBytes("uatom", "1")))
+	decoyTx := append(append([]byte(nil), tx[:bodyEnd]...), appendLengthDelimitedField(nil, 2, decoy)...)
+	decoyTx = append(decoyTx, tx[bodyEnd:]...)
+	circuit, assignment := buildAuthInfoCircuit(t, decoyTx, assertions, cfg, cheap, 200000)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted an auth_info_bytes shadowed by a later one")
+	}
+
+	// fee{1000000uatom} fee{1uatom, gas}: decoder merge thành
+	// Fee.amount = [1000000uatom, 1uatom].
+	var authInfo []byte
+	authInfo = appendLengthDelimitedField(authInfo, 2, appendLengthDelimitedField(nil, 1, coinBytes("uatom", "1000000")))
+	authInfo = appendLengthDelimitedField(authInfo, 2, testFee(200000, coinBytes("uatom", "1")))
+	circuit, assignment = buildAuthInfoCircuit(t, withAuthInfo(authInfo), assertions, cfg, cheap, 200000)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted the last of two merged fees")
+	}
+
+	// Đối chứng: cùng bố cục với một Fee duy nhất.
+	authInfo = appendLengthDelimitedField(nil, 2, testFee(200000, coinBytes("uatom", "1")))
+	circuit, assignment = buildAuthInfoCircuit(t, withAuthInfo(authInfo), assertions, cfg, cheap, 200000)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("single fee rejected: %v", err)
+	}
+}
+
+func buildAuthInfoCircuit(
+	t *testing.T,
+	tx []byte,
+	assertions []testAssertion,
+	cfg AuthInfoConfig,
+	coins []testCoin,
+	gasLimit uint64,
+) (*TxsFieldCircuit, *TxsFieldCircuit) {
+	t.Helper()
+	circuit, assignment := buildTestCircuit(t, tx, assertions)
+	circuit.WithAuthInfo(cfg)
+	assignment.WithAuthInfo(cfg)
+
+	_, bodyEnd := readLengthDelimited(t, tx, 1)
+	authInfo, _ := readLengthDelimited(t, tx, bodyEnd+1)
+	feeOffsets := fieldOffsets(t, authInfo, 0x12)
+	feeOffset := feeOffsets[len(feeOffsets)-1]
+	fee, _ := readLengthDelimited(t, authInfo, feeOffset+1)
+	gasOffsets := fieldOffsets(t, fee, 0x10)
+	coinOffsets := fieldOffsets(t, fee, 0x0a)
+
+	a := &assignment.AuthInfo[0]
+	a.FeeOffset = feeOffset
+	a.GasLimitOffset = gasOffsets[len(gasOffsets)-1]
+	a.GasLimit = gasLimit
+	for k := range a.FeeCoins {
+		a.FeeCoins[k].Offset = coinOffsets[k]
+		a.FeeCoins[k].DenomLen = len(coins[k].Denom)
+		a.FeeCoins[k].AmountLen = len(coins[k].Amount)
+		padBytes(a.FeeCoins[k].Denom, []byte(coins[k].Denom))
+		padBytes(a.FeeCoins[k].Amount, []byte(coins[k].Amount))
+	}
+	return circuit, assignment
+}
+
+// padBytes ghi data vào đầu dst và pad 0 phần còn lại.
+func padBytes(dst []frontend.Variable, data []byte) {
+	for j := range dst {
+		dst[j] = 0
+		if j < len(data) {
+			dst[j] = data[j]
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package txscircuit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// BenchmarkTxsFieldCircuitConstraints compile circuit cho hai kịch bản như
+// case1 (MsgSend + MsgDelegate) và case3 (MsgSend + MsgExecuteContract với
+// payload 5 KB) trong main.go và báo cáo số constraint.
+//
+// Đọc byte bằng mux O(n) mỗi lần: case1 ≈ 944k, case3 ≈ 4.86M constraint.
+// Với bảng lookup log-derivative (txBytes) và SubArray: case1 ≈ 61k, case3 ≈ 72k.
+// case3-payload chứng minh chính payload 5 KB (MsgExecuteContract.msg):
+// ≈ 107k, tức ~7 constraint cho mỗi byte của field value.
+func BenchmarkTxsFieldCircuitConstraints(b *testing.B) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	delegateValue := msgDelegateValue(testFromAddr, testValAddr, coinBytes("uatom", "1000"))
+
+	var execValue []byte
+	execValue = appendLengthDelimitedField(execValue, 1, []byte(testFromAddr))
+	execValue = appendLengthDelimitedField(execValue, 2, []byte(testToAddr))
+	execValue = appendLengthDelimitedField(execValue, 3, []byte(`{"payload":"`+strings.Repeat("x", 5_000)+`"}`))
+	execValue = appendLengthDelimitedField(execValue, 5, coinBytes("uatom", "1"))
+
+	benchmarks := []struct {
+		name   string
+		values [][]byte
+		keys   []byte
+		urls   []string
+	}{
+		{
+			name:   "case1",
+			values: [][]byte{sendValue, delegateValue},
+			keys:   []byte{0x1a, 0x1a},
+			urls:   []string{"/cosmos.bank.v1beta1.MsgSend", "/cosmos.staking.v1beta1.MsgDelegate"},
+		},
+		{
+			name:   "case3",
+			values: [][]byte{sendValue, execValue},
+			keys:   []byte{0x1a, 0x0a},
+			urls:   []string{"/cosmos.bank.v1beta1.MsgSend", "/cosmwasm.wasm.v1.MsgExecuteContract"},
+		},
+		{
+			name:   "case3-payload",
+			values: [][]byte{sendValue, execValue},
+			keys:   []byte{0x1a, 0x1a},
+			urls:   []string{"/cosmos.bank.v1beta1.MsgSend", "/cosmwasm.wasm.v1.MsgExecuteContract"},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			anyMsgs := make([][]byte, len(bm.values))
+			configs := make([]MsgConfig, len(bm.values))
+			for i, value := range bm.values {
+				anyMsgs[i] = anyBytes(bm.urls[i], value)
+				offsets := fieldOffsets(b, value, bm.keys[i])
+				fieldValue, _ := readLengthDelimited(b, value, offsets[len(offsets)-1]+1)
+				configs[i] = MsgConfig{
+					FieldValueLen: len(fieldValue),
+					MsgValueLen:   len(value),
+					MaxTypeURLLen: len(bm.urls[i]),
+				}
+			}
+			tx := buildTestTx(anyMsgs...)
+
+			var nbConstraints int
+			for b.Loop() {
+				ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, NewTxsFieldCircuit(len(tx), configs))
+				if err != nil {
+					b.Fatal(err)
+				}
+				nbConstraints = ccs.GetNbConstraints()
+			}
+			b.ReportMetric(float64(nbConstraints), "constraints")
+		})
+	}
+}
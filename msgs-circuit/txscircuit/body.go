@@ -0,0 +1,99 @@
+package txscircuit
+
+import "github.com/consensys/gnark/frontend"
+
+// DefaultMaxTailFields là số field tối đa sau danh sách message trong TxBody
+// khi BodyConfig.MaxTailFields = 0: memo, timeout_height, unordered,
+// timeout_timestamp.
+const DefaultMaxTailFields = 4
+
+// MemoAssertion công khai TxBody.memo, pad 0 tới BodyConfig.MaxMemoLen.
+// MemoLen = 0 khi tx không có memo.
+type MemoAssertion struct {
+	Memo    []frontend.Variable `gnark:",public"`
+	MemoLen frontend.Variable   `gnark:",public"`
+	// Offset là vị trí field memo trong tx; bỏ qua khi tx không có memo.
+	Offset frontend.Variable `gnark:",secret"`
+}
+
+// BodyConfig định nghĩa cách circuit kiểm tra phần TxBody sau các message.
+type BodyConfig struct {
+	// MaxTailFields giới hạn số field sau danh sách message; 0 dùng
+	// DefaultMaxTailFields.
+	MaxTailFields int
+	// MaxMemoLen > 0 bật MemoAssertion.
+	MaxMemoLen int
+	// AllowExtensionOptions chấp nhận extension_options và
+	// non_critical_extension_options; mặc định tx có chúng bị từ chối.
+	AllowExtensionOptions bool
+}
+
+func (cfg BodyConfig) maxTailFields() int {
+	if cfg.MaxTailFields > 0 {
+		return cfg.MaxTailFields
+	}
+	return DefaultMaxTailFields
+}
+
+// WithBody cấu hình phần kiểm tra cuối TxBody (dùng được với cả
+// NewTxsFieldCircuit và NewTxsFieldCircuitMaxLen).
+func (circuit *TxsFieldCircuit) WithBody(cfg BodyConfig) *TxsFieldCircuit {
+	circuit.Memo = nil
+	if cfg.MaxMemoLen > 0 {
+		circuit.Memo = []MemoAssertion{{Memo: make([]frontend.Variable, cfg.MaxMemoLen)}}
+	}
+	circuit.bodyConfig = cfg
+	return circuit
+}
+
+// verifyBodyTail duyệt các field trong [tailStart, bodyEnd), tức phần TxBody
+// sau message cuối cùng được chứng minh: không còn message nào (field 1), và
+// extension options chỉ được phép khi cấu hình cho phép. Nhờ vậy danh sách
+// Msgs là toàn bộ nội dung có ngữ nghĩa của body.
+func (circuit *TxsFieldCircuit) verifyBodyTail(
+	api frontend.API,
+	tx *txBytes,
+	tailStart frontend.Variable,
+	bodyEnd frontend.Variable,
+	maxIdx frontend.Variable,
+) {
+	cfg := circuit.bodyConfig
+	fields := tokenizeFieldsExt(api, tx, tailStart, bodyEnd, cfg.maxTailFields(), maxIdx, cfg.AllowExtensionOptions)
+	for _, field := range fields {
+		// Field number 1 với bất kỳ wire type nào.
+		isMsg := api.IsZero(api.Sub(field.Key, field.WireType, 0x08))
+		api.AssertIsEqual(api.Mul(field.Active, isMsg), 0)
+	}
+
+	for _, memo := range circuit.Memo {
+		verifyMemo(api, tx, fields, memo, maxIdx)
+	}
+}
+
+// verifyMemo ràng buộc MemoAssertion với occurrence cuối cùng của memo (key
+// 0x12) trong fields, hoặc MemoLen = 0 khi không có field memo.
+func verifyMemo(api frontend.API, tx *txBytes, fields []fieldToken, memo MemoAssertion, maxIdx frontend.Variable) {
+	memoCount := frontend.Variable(0)
+	for _, field := range fields {
+		memoCount = api.Add(memoCount, api.Mul(field.Active, api.IsZero(api.Sub(field.Key, 0x12))))
+	}
+	hasMemo := api.Sub(1, api.IsZero(memoCount))
+
+	// Không có memo thì đọc tại index 0 (tag body của TxRaw, luôn hợp lệ) và
+	// bỏ qua kết quả.
+	memoStart := api.Mul(hasMemo, memo.Offset)
+	hits := frontend.Variable(0)
+	for _, field := range fields {
+		sameKey := api.IsZero(api.Sub(field.Key, 0x12))
+		api.AssertIsEqual(api.Mul(hits, api.Mul(field.Active, sameKey)), 0)
+
+		isTarget := api.Mul(field.Active, sameKey, api.IsZero(api.Sub(field.Start, memoStart)))
+		hits = api.Add(hits, isTarget)
+	}
+	api.AssertIsEqual(hits, hasMemo)
+
+	lenIdx := api.Add(memoStart, 1)
+	memoLen, lenBytes := decodeVarint4Bytes(api, tx, lenIdx, maxIdx)
+	api.AssertIsEqual(memo.MemoLen, api.Mul(hasMemo, memoLen))
+	assertPaddedValue(api, tx, api.Add(lenIdx, lenBytes), memo.Memo, memo.MemoLen, maxIdx)
+}
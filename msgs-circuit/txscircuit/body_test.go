@@ -0,0 +1,133 @@
+package txscircuit
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+)
+
+func TestTxsFieldCircuitMemo(t *testing.T) {
+	const memo = "relayer: packet 42"
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTxWithMemo(memo, anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+	cfg := BodyConfig{MaxMemoLen: 32}
+
+	circuit, assignment := buildMemoTestCircuit(t, tx, assertions, cfg, memo)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("valid memo rejected: %v", err)
+	}
+
+	for _, wrong := range []string{"relayer: packet 43", ""} {
+		circuit, assignment = buildMemoTestCircuit(t, tx, assertions, cfg, wrong)
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatalf("circuit accepted memo %q", wrong)
+		}
+	}
+
+	// Không có memo: MemoLen = 0.
+	var body []byte
+	body = appendLengthDelimitedField(body, 1, anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	body = appendVarintField(body, 3, 123456)
+	tx = buildTestTxWithBody(body, testFee(300000, coinBytes("uatom", "5000")))
+	circuit, assignment = buildMemoTestCircuit(t, tx, assertions, cfg, "")
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("tx without memo rejected: %v", err)
+	}
+}
+
+func TestTxsFieldCircuitBodyTail(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	sendMsg := anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue)
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+	fee := testFee(300000, coinBytes("uatom", "5000"))
+
+	// Message thứ hai nằm sau memo không được bỏ sót.
+	var body []byte
+	body = appendLengthDelimitedField(body, 1, sendMsg)
+	body = appendLengthDelimitedField(body, 2, []byte("memo"))
+	body = appendLengthDelimitedField(body, 1, sendMsg)
+	circuit, assignment := buildTestCircuit(t, buildTestTxWithBody(body, fee), assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a message hidden after the memo")
+	}
+
+	// Field 1 mang wire type khác vẫn là field messages: decoder từ chối tx
+	// thay vì bỏ qua nó.
+	body = appendLengthDelimitedField(nil, 1, sendMsg)
+	body = appendVarintField(body, 1, 7)
+	circuit, assignment = buildTestCircuit(t, buildTestTxWithBody(body, fee), assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted field 1 with wire type 0 in the body tail")
+	}
+
+	// extension_options (field 1023) chỉ được chấp nhận khi cho phép.
+	body = appendLengthDelimitedField(nil, 1, sendMsg)
+	body = append(body, 0xfa, 0x3f)
+	extension := anyBytes("/cosmos.evm.vm.v1.ExtensionOptionsEthereumTx", nil)
+	body = append(body, encodeVarint(uint64(len(extension)))...)
+	body = append(body, extension...)
+	tx := buildTestTxWithBody(body, fee)
+
+	circuit, assignment = buildTestCircuit(t, tx, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted extension options by default")
+	}
+
+	cfg := BodyConfig{AllowExtensionOptions: true}
+	circuit, assignment = buildTestCircuit(t, tx, assertions)
+	circuit.WithBody(cfg)
+	assignment.WithBody(cfg)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("allowed extension options rejected: %v", err)
+	}
+}
+
+// TestTxsFieldCircuitVarintAtTxEnd: varint kết thúc ở byte cuối của tx (TxRaw
+// chỉ có body) vẫn phải decode được dù decoder đọc thử quá cuối tx.
+func TestTxsFieldCircuitVarintAtTxEnd(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	var body []byte
+	body = appendLengthDelimitedField(body, 1, anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	body = appendVarintField(body, 3, 300)
+	tx := appendLengthDelimitedField(nil, 1, body)
+
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("varint at the end of the tx rejected: %v", err)
+	}
+}
+
+func buildMemoTestCircuit(
+	t *testing.T,
+	tx []byte,
+	assertions []testAssertion,
+	cfg BodyConfig,
+	memo string,
+) (*TxsFieldCircuit, *TxsFieldCircuit) {
+	t.Helper()
+	circuit, assignment := buildTestCircuit(t, tx, assertions)
+	circuit.WithBody(cfg)
+	assignment.WithBody(cfg)
+
+	body, _ := readLengthDelimited(t, tx, 1)
+	bodyStart := 1 + len(encodeVarint(uint64(len(body))))
+	m := &assignment.Memo[0]
+	m.Offset = 0
+	for idx := 0; idx < len(body); {
+		if body[idx] == 0x12 {
+			m.Offset = bodyStart + idx
+		}
+		if body[idx]&0x07 == 0 {
+			_, n := decodeVarint(t, body[idx+1:])
+			idx += 1 + n
+			continue
+		}
+		_, idx = readLengthDelimited(t, body, idx+1)
+	}
+	m.MemoLen = len(memo)
+	padBytes(m.Memo, []byte(memo))
+	return circuit, assignment
+}
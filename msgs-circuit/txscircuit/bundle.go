@@ -0,0 +1,554 @@
+package txscircuit
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	frbls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	frbn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// BundleVersion là phiên bản định dạng ProofBundle hiện tại.
+const BundleVersion = 1
+
+const bundleBackend = "groth16"
+
+var ErrBundleVersion = errors.New("txscircuit: unsupported proof bundle")
+
+// ProofBundle là proof Groth16 của TxsFieldCircuit dạng JSON để trao đổi giữa
+// các service: điểm proof, public input đã decode về giá trị có nghĩa và shape
+// circuit. Public witness được dựng lại từ Public và Shape, nên verifier chỉ
+// cần thêm verifying key của ShapeKey.
+type ProofBundle struct {
+	Version  int                `json:"version"`
+	Curve    string             `json:"curve"`
+	Backend  string             `json:"backend"`
+	ShapeKey string             `json:"shape_key"`
+	Shape    BundleShape        `json:"shape"`
+	Proof    Groth16ProofPoints `json:"proof"`
+	Public   BundlePublic       `json:"public"`
+}
+
+// BundleShape là các tham số compile-time của circuit.
+type BundleShape struct {
+	TxCapacity  int         `json:"tx_capacity"`
+	VariableLen bool        `json:"variable_len"`
+	Msgs        []MsgConfig `json:"msgs"`
+}
+
+// BundlePublic là public input của TxsFieldCircuit.
+type BundlePublic struct {
+	TxHex string      `json:"tx_hex"`
+	TxLen int         `json:"tx_len"`
+	Msgs  []BundleMsg `json:"msgs"`
+}
+
+// BundleMsg là public input của một MsgAssertion. PathIndices[d] và
+// FieldIndex là phần tử được chọn của field repeated, 0 với field singular.
+type BundleMsg struct {
+	MsgIndex    int    `json:"msg_index"`
+	TypeURL     string `json:"type_url,omitempty"`
+	PathKeys    []int  `json:"path_keys,omitempty"`
+	PathIndices []int  `json:"path_indices,omitempty"`
+	FieldKey    int    `json:"field_key"`
+	FieldNumber int    `json:"field_number"`
+	FieldIndex  int    `json:"field_index,omitempty"`
+	ValueHex    string `json:"value_hex,omitempty"`
+	// ValueUTF8 chỉ có khi value là chuỗi UTF-8 in được (địa chỉ, denom, ...).
+	ValueUTF8 string `json:"value_utf8,omitempty"`
+	Varint    uint64 `json:"varint,omitempty,string"`
+}
+
+// Groth16ProofPoints là các điểm của proof Groth16 BN254, toạ độ dạng hex.
+type Groth16ProofPoints struct {
+	Ar            [2]string    `json:"ar"`
+	Bs            [2][2]string `json:"bs"`
+	Krs           [2]string    `json:"krs"`
+	Commitments   [][2]string  `json:"commitments,omitempty"`
+	CommitmentPok [2]string    `json:"commitment_pok"`
+}
+
+// NewProofBundle đóng gói proof cùng public input lấy từ assignment đã dùng
+// để prove. Circuit dùng WithBody/WithAuthInfo chưa được hỗ trợ.
+func NewProofBundle(proof groth16.Proof, assignment *TxsFieldCircuit) (*ProofBundle, error) {
+	p, ok := proof.(*groth16bn254.Proof)
+	if !ok {
+		return nil, fmt.Errorf("txscircuit: bundle needs a BN254 groth16 proof, got %T", proof)
+	}
+	if len(assignment.Memo) > 0 || len(assignment.AuthInfo) > 0 {
+		return nil, errors.New("txscircuit: bundle does not support memo or auth info assertions")
+	}
+
+	public, err := publicInputs(assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	points := Groth16ProofPoints{
+		Ar:            g1Hex(&p.Ar),
+		Bs:            g2Hex(&p.Bs),
+		Krs:           g1Hex(&p.Krs),
+		CommitmentPok: g1Hex(&p.CommitmentPok),
+	}
+	for i := range p.Commitments {
+		points.Commitments = append(points.Commitments, g1Hex(&p.Commitments[i]))
+	}
+
+	return &ProofBundle{
+		Version:  BundleVersion,
+		Curve:    ecc.BN254.String(),
+		Backend:  bundleBackend,
+		ShapeKey: assignment.ShapeKey(),
+		Shape:    assignment.Shape(),
+		Proof:    points,
+		Public:   public,
+	}, nil
+}
+
+// publicInputs đọc public input của assignment về giá trị có nghĩa.
+func publicInputs(assignment *TxsFieldCircuit) (BundlePublic, error) {
+	txLen, err := variableUint64(assignment.TxLen)
+	if err != nil {
+		return BundlePublic{}, fmt.Errorf("TxLen: %w", err)
+	}
+	txBytes, err := variableBytes(assignment.PublicTxBytes)
+	if err != nil {
+		return BundlePublic{}, fmt.Errorf("PublicTxBytes: %w", err)
+	}
+	if txLen > uint64(len(txBytes)) {
+		return BundlePublic{}, fmt.Errorf("txscircuit: TxLen %d exceeds capacity %d", txLen, len(txBytes))
+	}
+
+	msgs := make([]BundleMsg, len(assignment.Msgs))
+	for i, msg := range assignment.Msgs {
+		if msgs[i], err = newBundleMsg(i, msg); err != nil {
+			return BundlePublic{}, fmt.Errorf("msg %d: %w", i, err)
+		}
+	}
+	return BundlePublic{
+		TxHex: hex.EncodeToString(txBytes[:txLen]),
+		TxLen: int(txLen),
+		Msgs:  msgs,
+	}, nil
+}
+
+// DecodePublicWitness decode public witness của một proof có shape s (proof
+// Groth16 hay PLONK, BN254 hay BLS12-377) về giá trị có nghĩa, để verifier
+// thấy được proof khẳng định điều gì.
+func (s BundleShape) DecodePublicWitness(w witness.Witness) (BundlePublic, error) {
+	values, field, err := witnessValues(w)
+	if err != nil {
+		return BundlePublic{}, err
+	}
+	assignment := s.circuit()
+	tVariable := reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+	leaves, err := schema.Walk(field, assignment, tVariable, nil)
+	if err != nil {
+		return BundlePublic{}, err
+	}
+	if leaves.Public != len(values) {
+		return BundlePublic{}, fmt.Errorf("txscircuit: public witness has %d values, shape has %d", len(values), leaves.Public)
+	}
+
+	next := 0
+	if _, err := schema.Walk(field, assignment, tVariable, func(leaf schema.LeafInfo, tValue reflect.Value) error {
+		if leaf.Visibility == schema.Public {
+			tValue.Set(reflect.ValueOf(values[next]))
+			next++
+		}
+		return nil
+	}); err != nil {
+		return BundlePublic{}, err
+	}
+	return publicInputs(assignment)
+}
+
+// witnessValues trả về các giá trị public của w cùng field của curve.
+func witnessValues(w witness.Witness) ([]*big.Int, *big.Int, error) {
+	public, err := w.Public()
+	if err != nil {
+		return nil, nil, err
+	}
+	var values []*big.Int
+	switch vector := public.Vector().(type) {
+	case frbn254.Vector:
+		for i := range vector {
+			values = append(values, vector[i].BigInt(new(big.Int)))
+		}
+		return values, ecc.BN254.ScalarField(), nil
+	case frbls12377.Vector:
+		for i := range vector {
+			values = append(values, vector[i].BigInt(new(big.Int)))
+		}
+		return values, ecc.BLS12_377.ScalarField(), nil
+	default:
+		return nil, nil, fmt.Errorf("txscircuit: unsupported witness vector %T", vector)
+	}
+}
+
+func newBundleMsg(index int, msg MsgAssertion) (BundleMsg, error) {
+	typeURL, err := variableBytes(msg.TypeURL)
+	if err != nil {
+		return BundleMsg{}, fmt.Errorf("TypeURL: %w", err)
+	}
+	key, err := variableUint64(msg.Field.Key)
+	if err != nil {
+		return BundleMsg{}, fmt.Errorf("Field.Key: %w", err)
+	}
+	length, err := variableUint64(msg.Field.Len)
+	if err != nil {
+		return BundleMsg{}, fmt.Errorf("Field.Len: %w", err)
+	}
+	value, err := variableBytes(msg.Field.Value)
+	if err != nil {
+		return BundleMsg{}, fmt.Errorf("Field.Value: %w", err)
+	}
+	if length > uint64(len(value)) {
+		return BundleMsg{}, fmt.Errorf("txscircuit: Field.Len %d exceeds capacity %d", length, len(value))
+	}
+	varint, err := variableUint64(msg.Field.Varint)
+	if err != nil {
+		return BundleMsg{}, fmt.Errorf("Field.Varint: %w", err)
+	}
+	fieldIndex, err := variableUint64(msg.Field.Index)
+	if err != nil {
+		return BundleMsg{}, fmt.Errorf("Field.Index: %w", err)
+	}
+
+	out := BundleMsg{
+		MsgIndex:    index,
+		TypeURL:     strings.TrimRight(string(typeURL), "\x00"),
+		FieldKey:    int(key),
+		FieldNumber: int(key >> 3),
+		FieldIndex:  int(fieldIndex),
+		Varint:      varint,
+	}
+	for d, step := range msg.Path {
+		pathKey, err := variableUint64(step.Key)
+		if err != nil {
+			return BundleMsg{}, fmt.Errorf("Path[%d].Key: %w", d, err)
+		}
+		pathIndex, err := variableUint64(step.Index)
+		if err != nil {
+			return BundleMsg{}, fmt.Errorf("Path[%d].Index: %w", d, err)
+		}
+		out.PathKeys = append(out.PathKeys, int(pathKey))
+		out.PathIndices = append(out.PathIndices, int(pathIndex))
+	}
+	value = value[:length]
+	if len(value) > 0 {
+		out.ValueHex = hex.EncodeToString(value)
+		if isPrintableUTF8(value) {
+			out.ValueUTF8 = string(value)
+		}
+	}
+	return out, nil
+}
+
+// checkDecoded kiểm tra field_number và value_utf8 khớp với field_key và value
+// (value_hex đã decode). Hai trường này không vào public witness, nên nếu
+// không kiểm tra thì bundle verify thành công vẫn có thể hiển thị sai field.
+func (m BundleMsg) checkDecoded(value []byte) error {
+	if m.FieldNumber != m.FieldKey>>3 {
+		return fmt.Errorf("txscircuit: field_number %d does not match field_key %#x", m.FieldNumber, m.FieldKey)
+	}
+	var utf8Value string
+	if isPrintableUTF8(value) {
+		utf8Value = string(value)
+	}
+	if m.ValueUTF8 != utf8Value {
+		return fmt.Errorf("txscircuit: value_utf8 %q does not match value_hex", m.ValueUTF8)
+	}
+	return nil
+}
+
+// ReadProofBundle đọc bundle JSON và kiểm tra version, curve, backend, ShapeKey
+// khớp với Shape và các giá trị decode sẵn của từng msg khớp với public input.
+func ReadProofBundle(r io.Reader) (*ProofBundle, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var b ProofBundle
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("decode proof bundle: %w", err)
+	}
+	if b.Version != BundleVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrBundleVersion, b.Version)
+	}
+	if b.Curve != ecc.BN254.String() || b.Backend != bundleBackend {
+		return nil, fmt.Errorf("%w: %s/%s", ErrBundleVersion, b.Curve, b.Backend)
+	}
+	if key := b.circuit().ShapeKey(); key != b.ShapeKey {
+		return nil, fmt.Errorf("txscircuit: bundle shape hashes to %s, not %s", key, b.ShapeKey)
+	}
+	for i, msg := range b.Public.Msgs {
+		value, err := hex.DecodeString(msg.ValueHex)
+		if err != nil {
+			return nil, fmt.Errorf("msg %d value_hex: %w", i, err)
+		}
+		if err := msg.checkDecoded(value); err != nil {
+			return nil, fmt.Errorf("msg %d: %w", i, err)
+		}
+	}
+	return &b, nil
+}
+
+// WriteTo ghi bundle dạng JSON có thụt lề.
+func (b *ProofBundle) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// circuit dựng circuit rỗng theo Shape.
+func (b *ProofBundle) circuit() *TxsFieldCircuit {
+	return b.Shape.circuit()
+}
+
+// Shape trả về shape của circuit (không gồm Memo/AuthInfo, như ProofBundle).
+func (circuit *TxsFieldCircuit) Shape() BundleShape {
+	return BundleShape{
+		TxCapacity:  len(circuit.PublicTxBytes),
+		VariableLen: circuit.variableLen,
+		Msgs:        circuit.msgConfigs,
+	}
+}
+
+// circuit dựng circuit rỗng theo s.
+func (s BundleShape) circuit() *TxsFieldCircuit {
+	if s.VariableLen {
+		return NewTxsFieldCircuitMaxLen(s.TxCapacity, s.Msgs)
+	}
+	return NewTxsFieldCircuit(s.TxCapacity, s.Msgs)
+}
+
+// ShapeKey là ShapeKey của circuit có shape s.
+func (s BundleShape) ShapeKey() string {
+	return s.circuit().ShapeKey()
+}
+
+// GetProof dựng lại groth16.Proof từ các điểm trong bundle; mọi điểm phải nằm
+// trên curve và trong subgroup.
+func (b *ProofBundle) GetProof() (groth16.Proof, error) {
+	var p groth16bn254.Proof
+	var err error
+	if p.Ar, err = g1FromHex(b.Proof.Ar); err != nil {
+		return nil, fmt.Errorf("ar: %w", err)
+	}
+	if p.Bs, err = g2FromHex(b.Proof.Bs); err != nil {
+		return nil, fmt.Errorf("bs: %w", err)
+	}
+	if p.Krs, err = g1FromHex(b.Proof.Krs); err != nil {
+		return nil, fmt.Errorf("krs: %w", err)
+	}
+	if p.CommitmentPok, err = g1FromHex(b.Proof.CommitmentPok); err != nil {
+		return nil, fmt.Errorf("commitment_pok: %w", err)
+	}
+	p.Commitments = make([]curve.G1Affine, len(b.Proof.Commitments))
+	for i, c := range b.Proof.Commitments {
+		if p.Commitments[i], err = g1FromHex(c); err != nil {
+			return nil, fmt.Errorf("commitments[%d]: %w", i, err)
+		}
+	}
+	return &p, nil
+}
+
+// PublicWitness dựng lại public witness từ các giá trị đã decode.
+func (b *ProofBundle) PublicWitness() (witness.Witness, error) {
+	assignment := b.circuit()
+	if len(b.Public.Msgs) != len(assignment.Msgs) {
+		return nil, fmt.Errorf("txscircuit: bundle has %d msgs, shape has %d", len(b.Public.Msgs), len(assignment.Msgs))
+	}
+
+	txBytes, err := hex.DecodeString(b.Public.TxHex)
+	if err != nil {
+		return nil, fmt.Errorf("tx_hex: %w", err)
+	}
+	if len(txBytes) != b.Public.TxLen || len(txBytes) > len(assignment.PublicTxBytes) {
+		return nil, fmt.Errorf("txscircuit: tx_len %d does not match tx_hex (%d bytes, capacity %d)", b.Public.TxLen, len(txBytes), len(assignment.PublicTxBytes))
+	}
+	padVariables(assignment.PublicTxBytes, txBytes)
+	assignment.TxLen = b.Public.TxLen
+
+	for i, msg := range b.Public.Msgs {
+		out := &assignment.Msgs[i]
+		if msg.MsgIndex != i || len(msg.TypeURL) > len(out.TypeURL) ||
+			len(msg.PathKeys) != len(out.Path) || len(msg.PathIndices) != len(out.Path) {
+			return nil, fmt.Errorf("txscircuit: msg %d does not match the bundle shape", i)
+		}
+		value, err := hex.DecodeString(msg.ValueHex)
+		if err != nil {
+			return nil, fmt.Errorf("msg %d value_hex: %w", i, err)
+		}
+		if len(value) > len(out.Field.Value) {
+			return nil, fmt.Errorf("txscircuit: msg %d value longer than shape allows", i)
+		}
+		if err := msg.checkDecoded(value); err != nil {
+			return nil, fmt.Errorf("msg %d: %w", i, err)
+		}
+
+		padVariables(out.TypeURL, []byte(msg.TypeURL))
+		for d, key := range msg.PathKeys {
+			out.Path[d].Key = key
+			out.Path[d].Index = msg.PathIndices[d]
+		}
+		out.Field.Key = msg.FieldKey
+		out.Field.Index = msg.FieldIndex
+		padVariables(out.Field.Value, value)
+		out.Field.Len = len(value)
+		out.Field.Varint = msg.Varint
+	}
+
+	return frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+}
+
+// Verify kiểm tra bundle với verifying key của ShapeKey.
+func (b *ProofBundle) Verify(vk groth16.VerifyingKey) error {
+	proof, err := b.GetProof()
+	if err != nil {
+		return err
+	}
+	publicWitness, err := b.PublicWitness()
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(proof, vk, publicWitness)
+}
+
+func padVariables(dst []frontend.Variable, data []byte) {
+	for i := range dst {
+		dst[i] = 0
+		if i < len(data) {
+			dst[i] = data[i]
+		}
+	}
+}
+
+// variableUint64 đọc giá trị hằng của một biến trong assignment.
+func variableUint64(v frontend.Variable) (uint64, error) {
+	switch x := v.(type) {
+	case uint8:
+		return uint64(x), nil
+	case uint64:
+		return x, nil
+	case int:
+		if x >= 0 {
+			return uint64(x), nil
+		}
+	case *big.Int:
+		if x.IsUint64() {
+			return x.Uint64(), nil
+		}
+	case big.Int:
+		if x.IsUint64() {
+			return x.Uint64(), nil
+		}
+	case nil:
+		return 0, errors.New("unassigned variable")
+	}
+	return 0, fmt.Errorf("unsupported assignment value %v (%T)", v, v)
+}
+
+func variableBytes(vars []frontend.Variable) ([]byte, error) {
+	out := make([]byte, len(vars))
+	for i, v := range vars {
+		x, err := variableUint64(v)
+		if err != nil {
+			return nil, err
+		}
+		if x > 0xff {
+			return nil, fmt.Errorf("value %d at %d is not a byte", x, i)
+		}
+		out[i] = byte(x)
+	}
+	return out, nil
+}
+
+func isPrintableUTF8(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func fpHex(e *fp.Element) string {
+	return "0x" + e.Text(16)
+}
+
+func g1Hex(p *curve.G1Affine) [2]string {
+	return [2]string{fpHex(&p.X), fpHex(&p.Y)}
+}
+
+func g2Hex(p *curve.G2Affine) [2][2]string {
+	return [2][2]string{
+		{fpHex(&p.X.A0), fpHex(&p.X.A1)},
+		{fpHex(&p.Y.A0), fpHex(&p.Y.A1)},
+	}
+}
+
+func fpFromHex(s string) (fp.Element, error) {
+	var e fp.Element
+	if !strings.HasPrefix(s, "0x") {
+		return e, fmt.Errorf("coordinate %q is not 0x-prefixed hex", s)
+	}
+	v, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok || v.Cmp(fp.Modulus()) >= 0 {
+		return e, fmt.Errorf("coordinate %q is not a field element", s)
+	}
+	e.SetBigInt(v)
+	return e, nil
+}
+
+func g1FromHex(c [2]string) (curve.G1Affine, error) {
+	var p curve.G1Affine
+	var err error
+	if p.X, err = fpFromHex(c[0]); err != nil {
+		return p, err
+	}
+	if p.Y, err = fpFromHex(c[1]); err != nil {
+		return p, err
+	}
+	if !p.IsInSubGroup() {
+		return p, errors.New("point not on curve or not in subgroup")
+	}
+	return p, nil
+}
+
+func g2FromHex(c [2][2]string) (curve.G2Affine, error) {
+	var p curve.G2Affine
+	coords := []*fp.Element{&p.X.A0, &p.X.A1, &p.Y.A0, &p.Y.A1}
+	for i, s := range []string{c[0][0], c[0][1], c[1][0], c[1][1]} {
+		e, err := fpFromHex(s)
+		if err != nil {
+			return p, err
+		}
+		*coords[i] = e
+	}
+	if !p.IsInSubGroup() {
+		return p, errors.New("point not on curve or not in subgroup")
+	}
+	return p, nil
+}
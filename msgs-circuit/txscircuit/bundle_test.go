@@ -0,0 +1,243 @@
+package txscircuit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+)
+
+// bundleTestAssignment: MsgSend.amount.denom (có type URL) và
+// MsgDelegate.amount trong circuit độ dài biến thiên.
+func bundleTestAssignment(t *testing.T) *TxsFieldCircuit {
+	t.Helper()
+	const sendType = "/cosmos.bank.v1beta1.MsgSend"
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	delegateValue := msgDelegateValue(testFromAddr, testValAddr, coinBytes("stake", "777"))
+	tx := buildTestTx(anyBytes(sendType, sendValue), anyBytes("/cosmos.staking.v1beta1.MsgDelegate", delegateValue))
+
+	send := nestedFieldAssertion(t, sendValue, 0x0a, 0x1a)
+	send.TypeURL = sendType
+	delegate := lastFieldAssertion(t, delegateValue, 0x1a)
+	delegate.MaxValueLen = 32
+	_, assignment := buildTestCircuitMaxLen(t, len(tx)+16, tx, []testAssertion{send, delegate})
+	return assignment
+}
+
+// fakeProof dùng các generator làm điểm proof: đủ để kiểm tra encode/decode.
+func fakeProof() *groth16bn254.Proof {
+	_, _, g1, g2 := curve.Generators()
+	return &groth16bn254.Proof{Ar: g1, Bs: g2, Krs: g1, Commitments: []curve.G1Affine{g1}, CommitmentPok: g1}
+}
+
+func TestProofBundleRoundTrip(t *testing.T) {
+	assignment := bundleTestAssignment(t)
+	bundle, err := NewProofBundle(fakeProof(), assignment)
+	if err != nil {
+		t.Fatalf("NewProofBundle: %v", err)
+	}
+	if got := bundle.Public.Msgs[0]; got.ValueUTF8 != "uatom" || got.TypeURL != "/cosmos.bank.v1beta1.MsgSend" || len(got.PathKeys) != 1 || got.PathKeys[0] != 0x1a {
+		t.Fatalf("decoded msg 0 = %+v", got)
+	}
+	if got := bundle.Public.Msgs[1]; got.FieldNumber != 3 || got.ValueUTF8 != "" || got.ValueHex == "" {
+		t.Fatalf("decoded msg 1 = %+v", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := bundle.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadProofBundle(&buf)
+	if err != nil {
+		t.Fatalf("ReadProofBundle: %v", err)
+	}
+
+	proof, err := loaded.GetProof()
+	if err != nil {
+		t.Fatalf("GetProof: %v", err)
+	}
+	if !proof.(*groth16bn254.Proof).Ar.Equal(&fakeProof().Ar) {
+		t.Fatal("proof point changed in round trip")
+	}
+
+	publicWitness, err := loaded.PublicWitness()
+	if err != nil {
+		t.Fatalf("PublicWitness: %v", err)
+	}
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := publicWitness.MarshalBinary()
+	want, _ := wantWitness.MarshalBinary()
+	if !bytes.Equal(got, want) {
+		t.Fatal("re-hydrated public witness differs from the prover's")
+	}
+}
+
+// TestDecodePublicWitness: public witness (BN254 lẫn BLS12-377) decode về
+// đúng public input của assignment, và shape khác bị từ chối.
+func TestDecodePublicWitness(t *testing.T) {
+	assignment := bundleTestAssignment(t)
+	want, err := publicInputs(assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape := assignment.Shape()
+
+	for _, curveID := range []ecc.ID{ecc.BN254, ecc.BLS12_377} {
+		w, err := frontend.NewWitness(assignment, curveID.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := shape.DecodePublicWitness(w)
+		if err != nil {
+			t.Fatalf("%s: %v", curveID, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: decoded %+v, want %+v", curveID, got, want)
+		}
+	}
+
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape.TxCapacity++
+	if _, err := shape.DecodePublicWitness(w); err == nil {
+		t.Fatal("decoded a public witness with a different shape")
+	}
+}
+
+func TestReadProofBundleErrors(t *testing.T) {
+	bundle, err := NewProofBundle(fakeProof(), bundleTestAssignment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(b ProofBundle) *bytes.Buffer {
+		var buf bytes.Buffer
+		if _, err := b.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return &buf
+	}
+
+	future := *bundle
+	future.Version = BundleVersion + 1
+	if _, err := ReadProofBundle(encode(future)); !errors.Is(err, ErrBundleVersion) {
+		t.Errorf("future version: err = %v, want %v", err, ErrBundleVersion)
+	}
+
+	reshaped := *bundle
+	reshaped.Shape.TxCapacity++
+	if _, err := ReadProofBundle(encode(reshaped)); err == nil {
+		t.Error("accepted a shape that does not match shape_key")
+	}
+
+	offCurve := *bundle
+	offCurve.Proof.Ar[1] = "0x1"
+	loaded, err := ReadProofBundle(encode(offCurve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loaded.GetProof(); err == nil {
+		t.Error("accepted a proof point off the curve")
+	}
+
+	// field_number và value_utf8 phải khớp field_key và value_hex.
+	for name, tamper := range map[string]func(*BundleMsg){
+		"field_number": func(m *BundleMsg) { m.FieldNumber++ },
+		"value_utf8":   func(m *BundleMsg) { m.ValueUTF8 = "uosmo" },
+		"value_hex":    func(m *BundleMsg) { m.ValueHex = hex.EncodeToString([]byte("uosmo")) },
+	} {
+		tampered := *bundle
+		tampered.Public.Msgs = append([]BundleMsg(nil), bundle.Public.Msgs...)
+		tamper(&tampered.Public.Msgs[0])
+		if _, err := ReadProofBundle(encode(tampered)); err == nil {
+			t.Errorf("accepted a bundle whose %s was changed alone", name)
+		}
+		if _, err := tampered.PublicWitness(); err == nil {
+			t.Errorf("PublicWitness accepted a bundle whose %s was changed alone", name)
+		}
+	}
+
+	unknown := strings.Replace(encode(*bundle).String(), `"version"`, `"extra": 1, "version"`, 1)
+	if _, err := ReadProofBundle(strings.NewReader(unknown)); err == nil {
+		t.Error("accepted an unknown field")
+	}
+}
+
+// TestProofBundleVerify prove thật rồi verify qua bundle JSON; sửa value trong
+// bundle phải làm verify thất bại.
+func TestProofBundleVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("groth16 setup is slow")
+	}
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{nestedFieldAssertion(t, sendValue, 0x12, 0x1a)})
+
+	artifacts, _, err := NewArtifactStore(t.TempDir()).LoadOrSetup(circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(artifacts.CS, artifacts.PK, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := NewProofBundle(proof, assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := bundle.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadProofBundle(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Verify(artifacts.VK); err != nil {
+		t.Fatalf("verify bundle: %v", err)
+	}
+
+	// "4242" -> "4243", value_utf8 sửa theo để chỉ proof còn bắt được.
+	tampered := *loaded
+	tampered.Public.Msgs = append([]BundleMsg(nil), loaded.Public.Msgs...)
+	tampered.Public.Msgs[0].ValueHex = "34323433"
+	tampered.Public.Msgs[0].ValueUTF8 = "4243"
+	if err := tampered.Verify(artifacts.VK); err == nil {
+		t.Fatal("verified a bundle with a tampered field value")
+	}
+
+	// Trường chỉ để hiển thị bị sửa riêng: public witness không đổi nhưng bundle
+	// vẫn phải bị từ chối.
+	tampered.Public.Msgs[0] = loaded.Public.Msgs[0]
+	tampered.Public.Msgs[0].ValueUTF8 = "4243"
+	if err := tampered.Verify(artifacts.VK); err == nil {
+		t.Fatal("verified a bundle with a tampered value_utf8")
+	}
+	tampered.Public.Msgs[0] = loaded.Public.Msgs[0]
+	tampered.Public.Msgs[0].FieldNumber = 1
+	if err := tampered.Verify(artifacts.VK); err == nil {
+		t.Fatal("verified a bundle with a tampered field_number")
+	}
+}
@@ -7,32 +7,124 @@ import (
 )
 
 // FieldPublic chứa key + value (length-delimited field) làm public input.
+// Len là độ dài thật của value: bằng len(Value) khi MsgConfig.FieldValueLen cố
+// định, hoặc <= MaxFieldValueLen và Value được pad 0 phía sau.
+//
+// Với MsgConfig.VarintField (wire type 0), Value rỗng, Len = 0 và Varint là
+// số nguyên uint64 đã decode; với field length-delimited Varint = 0.
+//
+// Index là vị trí (đếm từ 0) của phần tử trong field repeated
+// (MsgConfig.RepeatedField); field singular có Index = 0.
 type FieldPublic struct {
-	Key   frontend.Variable   `gnark:",public"`
-	Value []frontend.Variable `gnark:",public"`
+	Key    frontend.Variable   `gnark:",public"`
+	Value  []frontend.Variable `gnark:",public"`
+	Len    frontend.Variable   `gnark:",public"`
+	Varint frontend.Variable   `gnark:",public"`
+	Index  frontend.Variable   `gnark:",public"`
+}
+
+// PathStep là một bước trên đường dẫn tới field lồng nhau: field
+// length-delimited (sub-message) có key Key, nằm tại Offset tính từ đầu vùng
+// chứa nó (message value hoặc payload của bước trước). Index chọn phần tử khi
+// bước là field repeated (MsgConfig.RepeatedPath), ngược lại bằng 0.
+type PathStep struct {
+	Key    frontend.Variable `gnark:",public"`
+	Index  frontend.Variable `gnark:",public"`
+	Offset frontend.Variable `gnark:",secret"`
 }
 
 // MsgAssertion mô tả một message trong TxBody chúng ta muốn kiểm chứng.
+//
+// TypeURL là Any.type_url của message, pad 0 tới MsgConfig.MaxTypeURLLen; nhờ
+// đó verifier biết assertion nói về loại Msg nào (vd. MsgSend) chứ không chỉ
+// "field 3 của một message bất kỳ".
+//
+// Path rỗng: Field là field trực tiếp của message value. Ngược lại Field nằm
+// trong payload của Path[len(Path)-1], ví dụ MsgSend.amount[1].denom có
+// Path = [{Key: 0x1a, Index: 1}] (amount là repeated) và Field.Key = 0x0a;
+// FieldOffset khi đó tính từ đầu payload của bước cuối.
 type MsgAssertion struct {
+	TypeURL     []frontend.Variable `gnark:",public"`
+	Path        []PathStep
 	Field       FieldPublic
 	FieldOffset frontend.Variable `gnark:",secret"`
 	BodyOffset  frontend.Variable `gnark:",secret"`
 }
 
 // MsgConfig định nghĩa kích thước cố định cho từng message assertion.
+//
+// MaxFieldValueLen > 0 bật chế độ độ dài biến thiên cho field value (bỏ qua
+// FieldValueLen); MsgValueLen = 0 cho phép message value có độ dài bất kỳ.
 type MsgConfig struct {
-	FieldValueLen int
-	MsgValueLen   int
+	FieldValueLen    int
+	MaxFieldValueLen int
+	MsgValueLen      int
+	// MaxFields giới hạn số field trong message value (và trong mỗi
+	// sub-message trên Path) mà circuit duyệt qua; 0 dùng DefaultMaxMsgFields.
+	MaxFields int
+	// PathDepth là số sub-message cần đi xuống trước khi tới field (len(Path)).
+	PathDepth int
+	// MaxTypeURLLen là sức chứa của MsgAssertion.TypeURL; 0 bỏ qua kiểm tra
+	// type URL.
+	MaxTypeURLLen int
+	// VarintField chứng minh field wire type 0 (vd. timeout, sequence) thay vì
+	// field length-delimited; giá trị nằm ở FieldPublic.Varint.
+	VarintField bool
+	// RepeatedPath[d] = true khi Path[d] là field repeated (vd. MsgSend.amount):
+	// bước đó chọn phần tử thứ Path[d].Index. Rỗng hoặc ngắn hơn PathDepth:
+	// các bước còn lại là singular và phải xuất hiện đúng một lần.
+	RepeatedPath []bool
+	// RepeatedField tương tự cho Field, chọn phần tử thứ Field.Index.
+	RepeatedField bool
+	// MessageField = true khi Field singular là sub-message (vd.
+	// MsgDelegate.amount): decoder merge các occurrence của nó nên, như bước
+	// singular trên Path, Field phải xuất hiện đúng một lần. Field string/bytes
+	// giữ nguyên ngữ nghĩa occurrence cuối cùng.
+	MessageField bool
+}
+
+func (cfg MsgConfig) repeatedStep(d int) bool {
+	return d < len(cfg.RepeatedPath) && cfg.RepeatedPath[d]
+}
+
+func (cfg MsgConfig) fieldValueCap() int {
+	if cfg.VarintField {
+		return 0
+	}
+	if cfg.MaxFieldValueLen > 0 {
+		return cfg.MaxFieldValueLen
+	}
+	return cfg.FieldValueLen
+}
+
+func (cfg MsgConfig) maxFields() int {
+	if cfg.MaxFields > 0 {
+		return cfg.MaxFields
+	}
+	return DefaultMaxMsgFields
 }
 
 // TxsFieldCircuit chứng minh TxBytes chứa nhiều Msg (có thể >1) và mỗi Msg
 // có Field length-delimited khớp public input.
+//
+// TxLen là độ dài thật của tx. Với NewTxsFieldCircuit nó phải bằng
+// len(PublicTxBytes); với NewTxsFieldCircuitMaxLen, PublicTxBytes có sức chứa
+// MaxTxLen, các byte từ TxLen trở đi phải bằng 0 và một proving key dùng được
+// cho mọi tx có độ dài <= MaxTxLen.
 type TxsFieldCircuit struct {
 	PublicTxBytes []frontend.Variable `gnark:",public"`
+	TxLen         frontend.Variable   `gnark:",public"`
 	Msgs          []MsgAssertion
-
-	msgConfigs  []MsgConfig
-	txIndexBits int
+	// Memo rỗng trừ khi bật bằng WithBody.
+	Memo []MemoAssertion
+	// AuthInfo rỗng trừ khi bật bằng WithAuthInfo.
+	AuthInfo []AuthInfoAssertion
+
+	msgConfigs     []MsgConfig
+	bodyConfig     BodyConfig
+	authInfoConfig AuthInfoConfig
+	txIndexBits    int
+	variableLen    bool
 }
 
 // NewTxsFieldCircuit builds a circuit configured for the given Tx length and
@@ -43,7 +135,12 @@ func NewTxsFieldCircuit(txLen int, configs []MsgConfig) *TxsFieldCircuit {
 	}
 	msgs := make([]MsgAssertion, len(configs))
 	for i, cfg := range configs {
-		msgs[i].Field.Value = make([]frontend.Variable, cfg.FieldValueLen)
+		if len(cfg.RepeatedPath) > cfg.PathDepth {
+			panic("RepeatedPath longer than PathDepth")
+		}
+		msgs[i].TypeURL = make([]frontend.Variable, cfg.MaxTypeURLLen)
+		msgs[i].Path = make([]PathStep, cfg.PathDepth)
+		msgs[i].Field.Value = make([]frontend.Variable, cfg.fieldValueCap())
 	}
 
 	return &TxsFieldCircuit{
@@ -54,62 +151,104 @@ func NewTxsFieldCircuit(txLen int, configs []MsgConfig) *TxsFieldCircuit {
 	}
 }
 
+// NewTxsFieldCircuitMaxLen builds a circuit whose PublicTxBytes has capacity
+// maxTxLen; the real length is supplied through the TxLen public input.
+func NewTxsFieldCircuitMaxLen(maxTxLen int, configs []MsgConfig) *TxsFieldCircuit {
+	circuit := NewTxsFieldCircuit(maxTxLen, configs)
+	circuit.variableLen = true
+	return circuit
+}
+
 func (circuit *TxsFieldCircuit) Define(api frontend.API) error {
 	tx := circuit.PublicTxBytes
-	if len(tx) == 0 {
+	if !circuit.variableLen {
+		api.AssertIsEqual(circuit.TxLen, len(tx))
+		circuit.verifyTx(api, newTxBytes(api, tx), len(tx))
+		return nil
+	}
+
+	// 1 <= TxLen <= MaxTxLen
+	api.ToBinary(api.Sub(circuit.TxLen, 1), circuit.txIndexBits)
+	api.ToBinary(api.Sub(len(tx), circuit.TxLen), circuit.txIndexBits)
+	assertZeroPadding(api, tx, circuit.TxLen)
+
+	circuit.verifyTx(api, newTxBytes(api, tx), circuit.TxLen)
+	return nil
+}
+
+// assertZeroPadding ràng buộc tx[i] = 0 với mọi i >= txLen.
+func assertZeroPadding(api frontend.API, tx []frontend.Variable, txLen frontend.Variable) {
+	isPadding := frontend.Variable(0)
+	for i := range tx {
+		// isPadding bật lên đúng một lần tại i = txLen và giữ nguyên sau đó.
+		isPadding = api.Add(isPadding, api.IsZero(api.Sub(i, txLen)))
+		api.AssertIsEqual(api.Mul(isPadding, tx[i]), 0)
+	}
+}
+
+// verifyTx ràng buộc tx[:txLen] là TxRaw chứa các message assertion của
+// circuit. Các biến thể (public bytes, tx hash, ...) chỉ khác nhau ở cách cung
+// cấp tx; txLen là hằng số khi độ dài tx cố định. Trả về vị trí ngay sau
+// body_bytes (tag của auth_info_bytes).
+func (circuit *TxsFieldCircuit) verifyTx(api frontend.API, tx *txBytes, txLen frontend.Variable) frontend.Variable {
+	if len(tx.vars) == 0 {
 		panic("empty tx")
 	}
 
-	api.AssertIsEqual(tx[0], 0x0a)
+	// Mọi lần đọc dữ liệu thật phải nằm trong [0, txLen).
+	maxIdx := api.Sub(txLen, 1)
+
+	api.AssertIsEqual(tx.vars[0], 0x0a)
 
 	// Decode body length using up to 4 bytes varint
 	bodyLenIdx := frontend.Variable(1)
-	bodyLen, bodyLenBytes := decodeVarint4Bytes(api, tx, bodyLenIdx, len(tx)-1)
+	bodyLen, bodyLenBytes := decodeVarint4Bytes(api, tx, bodyLenIdx, maxIdx)
 
 	// bodyStart = 1 (tag) + bodyLenBytes
 	bodyStart := api.Add(frontend.Variable(1), bodyLenBytes)
 	bodyEnd := api.Add(bodyStart, bodyLen)
-	api.AssertIsLessOrEqual(bodyEnd, frontend.Variable(len(tx)))
+	assertLessOrEqual(api, bodyEnd, txLen, circuit.txIndexBits+1)
+
+	// TxRaw chỉ gồm body_bytes (1), auth_info_bytes (2) và signatures (3),
+	// theo thứ tự tag không giảm như decoder của SDK đòi hỏi (ADR-027).
+	// Decoder dùng body_bytes cuối cùng, và ADR-027 chỉ cấm tag giảm dần nên
+	// [body mồi][body thật][auth_info][sig] vẫn hợp lệ: body được chứng minh
+	// (tại 0) phải là body_bytes duy nhất.
+	rawFields := tokenizeFields(api, tx, 0, txLen, MaxTxRawFields, maxIdx)
+	for i, field := range rawFields {
+		api.AssertIsEqual(api.Mul(field.Active, api.Sub(field.Key, 0x0a), api.Sub(field.Key, 0x12), api.Sub(field.Key, 0x1a)), 0)
+		if i > 0 {
+			step := api.Sub(field.Key, rawFields[i-1].Key)
+			api.AssertIsEqual(api.Mul(field.Active, step, api.Sub(step, 8), api.Sub(step, 16)), 0)
+		}
+	}
+	assertLastOccurrence(api, rawFields, 0x0a, 2, 0)
 
 	cursor := bodyStart
 	for i, msg := range circuit.Msgs {
 		api.ToBinary(msg.BodyOffset, circuit.txIndexBits)
 		api.AssertIsEqual(msg.BodyOffset, cursor)
-		cursor = circuit.verifyMessage(api, tx, msg, circuit.msgConfigs[i], bodyEnd)
+		cursor = circuit.verifyMessage(api, tx, msg, circuit.msgConfigs[i], bodyEnd, maxIdx)
 	}
 
-	//đảm bảo say txbody chỉ có memo, timeout height... chứ không còn msg nào khacs
-	maxIdx := len(tx) - 1
-
-	// api.Sub(cursor, bodyEnd) = 0 nếu ko có field nào khác tức là chỉ nguyên []msg, 1 nếu có memo, timeoutheight...
-	isAtEnd := api.IsZero(api.Sub(cursor, bodyEnd))
-
-	// If not at end, verify next byte is not a message tag (0x0a)
-	nextByte := selectByteAt(api, tx, cursor, maxIdx)
-
-	// là 1 khi byte tiếp theo là 0x0a tức là field 1,w =2
-	isNotMsgTag := api.IsZero(api.Sub(nextByte, 0x0a))
+	// Phần còn lại của body (memo, timeout height, ...) không được chứa thêm
+	// message nào.
+	circuit.verifyBodyTail(api, tx, cursor, bodyEnd, maxIdx)
 
-	// Assert: (cursor == bodyEnd) OR (nextByte != 0x0a)
-	// Using: isAtEnd OR NOT(isNotMsgTag) = isAtEnd + (1 - isNotMsgTag) - isAtEnd*(1-isNotMsgTag) >= 1
-	notMsgTagOrEnd := api.Sub(
-		api.Add(isAtEnd, api.Sub(1, isNotMsgTag)), //
-		api.Mul(isAtEnd, api.Sub(1, isNotMsgTag)),
-	)
-	api.AssertIsEqual(notMsgTagOrEnd, 1)
-
-	return nil
+	for _, assertion := range circuit.AuthInfo {
+		circuit.verifyAuthInfo(api, tx, assertion, rawFields, bodyEnd, txLen, maxIdx)
+	}
+	return bodyEnd
 }
 
 func (circuit *TxsFieldCircuit) verifyMessage(
 	api frontend.API,
-	tx []frontend.Variable,
+	tx *txBytes,
 	msg MsgAssertion,
 	cfg MsgConfig,
 	bodyEnd frontend.Variable,
+	maxIdx frontend.Variable,
 ) frontend.Variable {
-	maxIdx := len(tx) - 1
-
 	msgTag := selectByteAt(api, tx, msg.BodyOffset, maxIdx)
 	api.AssertIsEqual(msgTag, 0x0a)
 
@@ -130,6 +269,11 @@ func (circuit *TxsFieldCircuit) verifyMessage(
 	typeStart := api.Add(typeLenIdx, typeLenBytes)
 	api.AssertIsLessOrEqual(api.Add(typeStart, typeLen), api.Add(msgDataStart, msgLen))
 
+	if cfg.MaxTypeURLLen > 0 {
+		// typeLen <= MaxTypeURLLen, TypeURL = type_url || 0...
+		assertPaddedValue(api, tx, typeStart, msg.TypeURL, typeLen, maxIdx)
+	}
+
 	valueTagIdx := api.Add(typeStart, typeLen)
 	api.AssertIsEqual(selectByteAt(api, tx, valueTagIdx, maxIdx), 0x12)
 
@@ -142,14 +286,38 @@ func (circuit *TxsFieldCircuit) verifyMessage(
 	}
 
 	valueStart := api.Add(valueLenIdx, valueBytes)
+	valueEnd := api.Add(valueStart, valueLen)
+	// Any chỉ gồm {type_url, value}: field lặp lại phía sau value (type_url
+	// hoặc value thứ hai) sẽ thắng khi decode.
+	api.AssertIsEqual(valueEnd, api.Add(msgDataStart, msgLen))
+
+	// Đi xuống theo Path, mỗi bước thu hẹp vùng đang xét về payload của
+	// sub-message tương ứng.
+	regionStart, regionEnd := valueStart, valueEnd
+	for d, step := range msg.Path {
+		regionStart, regionEnd = circuit.descendPath(api, tx, step, cfg.repeatedStep(d), regionStart, regionEnd, cfg.maxFields(), maxIdx)
+	}
+	regionLen := api.Sub(regionEnd, regionStart)
 
 	api.ToBinary(msg.FieldOffset, circuit.txIndexBits)
-	api.AssertIsLessOrEqual(msg.FieldOffset, valueLen)
+	api.AssertIsLessOrEqual(msg.FieldOffset, regionLen)
 
 	// Verify field is at correct position by parsing from start
-	// Parse fields sequentially from valueStart until we reach msg.FieldOffset
+	// Parse fields sequentially from regionStart until we reach msg.FieldOffset
 	// This ensures fieldOffset points to actual field boundary, not arbitrary position
-	fieldStart := api.Add(valueStart, msg.FieldOffset)
+	fieldStart := api.Add(regionStart, msg.FieldOffset)
+	fields := tokenizeFields(api, tx, regionStart, regionEnd, cfg.maxFields(), maxIdx)
+	fieldWireType := 2
+	if cfg.VarintField {
+		fieldWireType = 0
+	}
+	if cfg.MessageField && !cfg.RepeatedField {
+		api.AssertIsEqual(msg.Field.Index, 0)
+		assertOnlyOccurrence(api, fields, msg.Field.Key, fieldWireType, fieldStart)
+	} else {
+		assertOccurrence(api, fields, msg.Field.Key, fieldWireType, fieldStart, msg.Field.Index, cfg.RepeatedField)
+	}
+
 	keyByte := selectByteAt(api, tx, fieldStart, maxIdx)
 	api.AssertIsEqual(keyByte, msg.Field.Key)
 	api.AssertIsLessOrEqual(msg.Field.Key, frontend.Variable(0x7f))
@@ -161,25 +329,40 @@ func (circuit *TxsFieldCircuit) verifyMessage(
 		api.Mul(keyBits[1], frontend.Variable(2)),
 		api.Mul(keyBits[2], frontend.Variable(4)),
 	)
-	api.AssertIsEqual(wireType, 2)
 
-	// Decode field length using up to 4 bytes varint
-	fieldLenIdx := api.Add(fieldStart, frontend.Variable(1))
-	fieldLen, fieldBytes := decodeVarint4Bytes(api, tx, fieldLenIdx, maxIdx)
-	api.AssertIsEqual(fieldLen, frontend.Variable(len(msg.Field.Value)))
+	var totalField frontend.Variable
+	if cfg.VarintField {
+		api.AssertIsEqual(wireType, 0)
+		api.AssertIsEqual(msg.Field.Len, 0)
 
-	fieldValueStart := api.Add(fieldLenIdx, fieldBytes)
-	for j := 0; j < len(msg.Field.Value); j++ {
-		idx := api.Add(fieldValueStart, frontend.Variable(j))
-		api.AssertIsEqual(selectByteAt(api, tx, idx, maxIdx), msg.Field.Value[j])
-	}
+		varint, varintBytes := decodeUint64Varint(api, tx, api.Add(fieldStart, 1), maxIdx)
+		api.AssertIsEqual(varint, msg.Field.Varint)
 
-	// totalField = 1 (key) + fieldBytes + len(Value)
-	totalField := api.Add(
-		api.Add(frontend.Variable(1), fieldBytes),
-		frontend.Variable(len(msg.Field.Value)),
-	)
-	api.AssertIsLessOrEqual(api.Add(msg.FieldOffset, totalField), valueLen)
+		// totalField = 1 (key) + varintBytes
+		totalField = api.Add(1, varintBytes)
+	} else {
+		api.AssertIsEqual(wireType, 2)
+		api.AssertIsEqual(msg.Field.Varint, 0)
+
+		// Decode field length using up to 4 bytes varint
+		fieldLenIdx := api.Add(fieldStart, frontend.Variable(1))
+		fieldLen, fieldBytes := decodeVarint4Bytes(api, tx, fieldLenIdx, maxIdx)
+		api.AssertIsEqual(fieldLen, msg.Field.Len)
+
+		fieldValueStart := api.Add(fieldLenIdx, fieldBytes)
+		if cfg.MaxFieldValueLen == 0 {
+			api.AssertIsEqual(msg.Field.Len, len(msg.Field.Value))
+		}
+		// Len <= len(Value)
+		assertPaddedValue(api, tx, fieldValueStart, msg.Field.Value, msg.Field.Len, maxIdx)
+
+		// totalField = 1 (key) + fieldBytes + Len
+		totalField = api.Add(
+			api.Add(frontend.Variable(1), fieldBytes),
+			msg.Field.Len,
+		)
+	}
+	api.AssertIsLessOrEqual(api.Add(msg.FieldOffset, totalField), regionLen)
 
 	// Đảm bảo field nằm hoàn toàn trong phạm vi message
 	msgDataEnd := api.Add(msgDataStart, msgLen)
@@ -238,147 +421,142 @@ func (circuit *TxsFieldCircuit) verifyMessage(
 	return entryEnd
 }
 
-func decodeVarintByte(api frontend.API, b frontend.Variable) (frontend.Variable, frontend.Variable) {
-	bits := api.ToBinary(b, 8)
-	value := frontend.Variable(0)
-	for i := 0; i < 7; i++ {
-		value = api.Add(value, api.Mul(bits[i], frontend.Variable(1<<i)))
+// descendPath chứng minh step là occurrence duy nhất của sub-message step.Key
+// trong [start, end) (hoặc phần tử thứ step.Index nếu repeated) và trả về vùng
+// payload [payloadStart, payloadEnd) của nó. Sub-message singular bị lặp lại
+// được decoder merge lại chứ không phải "last field wins", nên không có một
+// payload nào là giá trị decoder dùng: circuit từ chối trường hợp này.
+func (circuit *TxsFieldCircuit) descendPath(
+	api frontend.API,
+	tx *txBytes,
+	step PathStep,
+	repeated bool,
+	start frontend.Variable,
+	end frontend.Variable,
+	maxFields int,
+	maxIdx frontend.Variable,
+) (frontend.Variable, frontend.Variable) {
+	api.ToBinary(step.Offset, circuit.txIndexBits)
+	fieldStart := api.Add(start, step.Offset)
+
+	fields := tokenizeFields(api, tx, start, end, maxFields, maxIdx)
+	if repeated {
+		assertElementAt(api, fields, step.Key, 2, fieldStart, step.Index)
+	} else {
+		api.AssertIsEqual(step.Index, 0)
+		assertOnlyOccurrence(api, fields, step.Key, 2, fieldStart)
 	}
-	return value, bits[7]
-}
+	api.AssertIsEqual(selectByteAt(api, tx, fieldStart, maxIdx), step.Key)
 
-// decodeVarint4Bytes decodes a varint with up to 4 bytes support
-// Returns: (decoded value, number of bytes used)
-// Max value: 2^28 - 1 = 268,435,455 (~256MB)
-func decodeVarint4Bytes(api frontend.API, tx []frontend.Variable, startIdx frontend.Variable, maxIdx int) (frontend.Variable, frontend.Variable) {
-	// Read 4 potential bytes
-	byte1 := selectByteAt(api, tx, startIdx, maxIdx)
-	byte2Idx := api.Add(startIdx, 1)
-	byte2 := selectByteAt(api, tx, byte2Idx, maxIdx)
-	byte3Idx := api.Add(startIdx, 2)
-	byte3 := selectByteAt(api, tx, byte3Idx, maxIdx)
-	byte4Idx := api.Add(startIdx, 3)
-	byte4 := selectByteAt(api, tx, byte4Idx, maxIdx)
-
-	// Decode each byte
-	val1, msb1 := decodeVarintByte(api, byte1)
-	val2, msb2 := decodeVarintByte(api, byte2)
-	val3, msb3 := decodeVarintByte(api, byte3)
-	val4, msb4 := decodeVarintByte(api, byte4)
-
-	// Chỉ khi 3 byte đầu đều bật bit tiếp tục (msb = 1) thì byte thứ 4
-	// mới thuộc cùng varint và cần có msb = 0. Nếu varint kết thúc
-	// sớm thì byte4 là dữ liệu field kế tiếp, không thể cưỡng bức msb4=0.
-	// Gating: msb1*msb2*msb3 == 1 → msb4 phải = 0.
-	api.AssertIsEqual(api.Mul(msb1, api.Mul(msb2, api.Mul(msb3, msb4))), 0)
-
-	// Enforce canonical encoding (shortest form)
-	// If using 2 bytes (msb1=1), value must be >= 128
-	// If using 3 bytes (msb1=1, msb2=1), value must be >= 128^2 = 16384
-	// If using 4 bytes (msb1=1, msb2=1, msb3=1), value must be >= 128^3 = 2097152
-
-	// Check 2-byte canonical: if msb1=1, then val2 must be >= 1 (not 0)
-	// Because if val2=0 and msb1=1, it means value < 128 which should use 1 byte
-	isTwoByte := api.Mul(msb1, api.Sub(1, msb2)) // msb1=1 AND msb2=0
-	// If 2-byte, val2 >= 1 (hoặc val1 >= 128, nhưng val1 < 128 luôn)
-	val2IsNonZero := api.Sub(1, api.IsZero(val2))
-	shouldBeNonZero := api.Mul(isTwoByte, val2IsNonZero)
-	api.AssertIsEqual(shouldBeNonZero, isTwoByte) // Nếu dùng 2 bytes thì val2 > 0
-
-	// Check 3-byte canonical: if msb1=1, msb2=1, msb3=0, then val3 > 0
-	isThreeByte := api.Mul(msb1, api.Mul(msb2, api.Sub(1, msb3)))
-	val3IsNonZero := api.Sub(1, api.IsZero(val3))
-	shouldBeNonZero3 := api.Mul(isThreeByte, val3IsNonZero)
-	api.AssertIsEqual(shouldBeNonZero3, isThreeByte)
-
-	// Check 4-byte canonical: similar logic
-	isFourByte := api.Mul(msb1, api.Mul(msb2, msb3))
-	val4IsNonZero := api.Sub(1, api.IsZero(val4))
-	shouldBeNonZero4 := api.Mul(isFourByte, val4IsNonZero)
-	api.AssertIsEqual(shouldBeNonZero4, isFourByte)
-
-	// Calculate final value based on how many bytes are used
-	// value = val1 + (msb1 * val2 * 128) + (msb1 * msb2 * val3 * 128^2) + (msb1 * msb2 * msb3 * val4 * 128^3)
-
-	term1 := val1
-	term2 := api.Mul(msb1, api.Mul(val2, 128))
-	term3 := api.Mul(msb1, api.Mul(msb2, api.Mul(val3, 16384)))                  // 128^2 = 16384
-	term4 := api.Mul(msb1, api.Mul(msb2, api.Mul(msb3, api.Mul(val4, 2097152)))) // 128^3 = 2097152
-
-	value := api.Add(term1, api.Add(term2, api.Add(term3, term4)))
-
-	// Calculate number of bytes used: 1 + msb1 + (msb1 * msb2) + (msb1 * msb2 * msb3)
-	bytesUsed := api.Add(
-		1,
-		api.Add(
-			msb1,
-			api.Add(
-				api.Mul(msb1, msb2),
-				api.Mul(msb1, api.Mul(msb2, msb3)),
-			),
-		),
-	)
+	// Sub-message luôn là length-delimited (wire type 2).
+	keyBits := api.ToBinary(step.Key, 8)
+	api.AssertIsEqual(keyBits[0], 0)
+	api.AssertIsEqual(keyBits[1], 1)
+	api.AssertIsEqual(keyBits[2], 0)
 
-	return value, bytesUsed
+	lenIdx := api.Add(fieldStart, 1)
+	payloadLen, lenBytes := decodeVarint4Bytes(api, tx, lenIdx, maxIdx)
+	payloadStart := api.Add(lenIdx, lenBytes)
+	payloadEnd := api.Add(payloadStart, payloadLen)
+	assertLessOrEqual(api, payloadEnd, end, circuit.txIndexBits+1)
+
+	return payloadStart, payloadEnd
 }
 
-// selectByteAt selects tx[idx] using optimized binary tree approach
-// Complexity: O(log n) constraints instead of O(n)
-// TODO: Currently using linear for stability, will optimize to binary tree
-func selectByteAt(api frontend.API, tx []frontend.Variable, idx frontend.Variable, maxIdx int) frontend.Variable {
-	api.AssertIsLessOrEqual(frontend.Variable(0), idx)
-	api.AssertIsLessOrEqual(idx, frontend.Variable(maxIdx))
-
-	length := maxIdx + 1
-	pow2 := 1
-	numBits := 0
-	for pow2 < length {
-		pow2 <<= 1
-		numBits++
-	}
-	if numBits == 0 {
-		numBits = 1
+// assertPaddedValue ràng buộc length <= len(value), value[j] = tx[start+j]
+// với j < length và value[j] = 0 với j >= length.
+func assertPaddedValue(
+	api frontend.API,
+	tx *txBytes,
+	start frontend.Variable,
+	value []frontend.Variable,
+	length frontend.Variable,
+	maxIdx frontend.Variable,
+) {
+	window := subArray(api, tx, start, length, len(value), maxIdx)
+	for j := range value {
+		api.AssertIsEqual(value[j], window[j])
 	}
+}
 
-	idxBitsLE := api.ToBinary(idx, numBits)
-	idxBits := make([]frontend.Variable, numBits)
-	for i := 0; i < numBits; i++ {
-		idxBits[i] = idxBitsLE[numBits-1-i]
+// assertLastOccurrence chứng minh fieldStart là biên của một field trong
+// fields và không có field nào phía sau dùng lại cùng field number (protobuf
+// "last field wins"). key có wire type wireType; mọi field cùng field number
+// phải dùng đúng key đó, vì decoder từ chối field đã biết mang wire type khác.
+func assertLastOccurrence(api frontend.API, fields []fieldToken, key frontend.Variable, wireType int, fieldStart frontend.Variable) {
+	// fieldNumber << 3
+	numberBits := api.Sub(key, wireType)
+	hits := frontend.Variable(0)
+	for _, field := range fields {
+		sameNumber := api.Mul(field.Active, api.IsZero(api.Sub(field.Key, field.WireType, numberBits)))
+		api.AssertIsEqual(api.Mul(sameNumber, api.Sub(field.Key, key)), 0)
+		// Đã đi qua field được chứng minh mà còn gặp lại cùng field number =>
+		// field được chứng minh không phải giá trị mà decoder sẽ dùng.
+		api.AssertIsEqual(api.Mul(hits, sameNumber), 0)
+
+		isTarget := api.Mul(field.Active, api.IsZero(api.Sub(field.Start, fieldStart)))
+		hits = api.Add(hits, isTarget)
 	}
-
-	return selectByteAtPow2(api, tx, idxBits, 0, pow2, 0)
+	api.AssertIsEqual(hits, 1)
 }
 
-func selectByteAtPow2(
-	api frontend.API,
-	tx []frontend.Variable,
-	idxBits []frontend.Variable,
-	start int,
-	size int,
-	bitPos int,
-) frontend.Variable {
-	if size == 1 {
-		if start < len(tx) {
-			return tx[start]
-		}
-		return frontend.Variable(0)
+// assertOnlyOccurrence chứng minh fieldStart là biên của một field trong
+// fields và không có field nào khác cùng field number. Dùng cho sub-message
+// singular, vì decoder merge các occurrence thay vì lấy occurrence cuối cùng.
+func assertOnlyOccurrence(api frontend.API, fields []fieldToken, key frontend.Variable, wireType int, fieldStart frontend.Variable) {
+	numberBits := api.Sub(key, wireType)
+	hits := frontend.Variable(0)
+	count := frontend.Variable(0)
+	for _, field := range fields {
+		sameNumber := api.Mul(field.Active, api.IsZero(api.Sub(field.Key, field.WireType, numberBits)))
+		api.AssertIsEqual(api.Mul(sameNumber, api.Sub(field.Key, key)), 0)
+		count = api.Add(count, sameNumber)
+
+		isTarget := api.Mul(field.Active, api.IsZero(api.Sub(field.Start, fieldStart)))
+		hits = api.Add(hits, isTarget)
 	}
+	api.AssertIsEqual(hits, 1)
+	api.AssertIsEqual(count, 1)
+}
 
-	half := size / 2
-	left := selectByteAtPow2(api, tx, idxBits, start, half, bitPos+1)
-	right := selectByteAtPow2(api, tx, idxBits, start+half, half, bitPos+1)
-
-	if bitPos >= len(idxBits) {
-		return left
+// assertOccurrence chọn giữa field singular (occurrence cuối cùng, index = 0)
+// và phần tử thứ index của field repeated.
+func assertOccurrence(api frontend.API, fields []fieldToken, key frontend.Variable, wireType int, fieldStart, index frontend.Variable, repeated bool) {
+	if repeated {
+		assertElementAt(api, fields, key, wireType, fieldStart, index)
+		return
 	}
+	api.AssertIsEqual(index, 0)
+	assertLastOccurrence(api, fields, key, wireType, fieldStart)
+}
 
-	currentBit := idxBits[bitPos]
-	notBit := api.Sub(1, currentBit)
+// assertElementAt chứng minh fieldStart là biên của một field trong fields và
+// có đúng index field cùng field number đứng trước nó: field được chứng minh
+// là phần tử thứ index (đếm từ 0) của field repeated. Như assertLastOccurrence,
+// mọi field cùng field number phải dùng đúng key.
+func assertElementAt(api frontend.API, fields []fieldToken, key frontend.Variable, wireType int, fieldStart, index frontend.Variable) {
+	numberBits := api.Sub(key, wireType)
+	hits := frontend.Variable(0)
+	// seen là số field cùng field number đứng trước field đang xét.
+	seen := frontend.Variable(0)
+	position := frontend.Variable(0)
+	for _, field := range fields {
+		sameNumber := api.Mul(field.Active, api.IsZero(api.Sub(field.Key, field.WireType, numberBits)))
+		api.AssertIsEqual(api.Mul(sameNumber, api.Sub(field.Key, key)), 0)
+
+		isTarget := api.Mul(field.Active, api.IsZero(api.Sub(field.Start, fieldStart)))
+		hits = api.Add(hits, isTarget)
+		position = api.Add(position, api.Mul(isTarget, seen))
+		seen = api.Add(seen, sameNumber)
+	}
+	api.AssertIsEqual(hits, 1)
+	api.AssertIsEqual(position, index)
+}
 
-	return api.Add(
-		api.Mul(notBit, left),
-		api.Mul(currentBit, right),
-	)
+// assertLessOrEqual ràng buộc a <= b cho hai giá trị nhỏ (< 2^nbBits) bằng cách
+// range-check b - a; rẻ hơn api.AssertIsLessOrEqual khi b là biến.
+func assertLessOrEqual(api frontend.API, a, b frontend.Variable, nbBits int) {
+	api.ToBinary(api.Sub(b, a), nbBits)
 }
 
 func bitsFor(n int) int {
@@ -0,0 +1,874 @@
+package txscircuit
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+const (
+	testFromAddr = "cosmos18qqv7rruzf82htyqzn7g3f93722exlmckte82g"
+	testToAddr   = "cosmos17d2ar63s0qyvnd2z9esny4yy0vnw0exxctc2ny"
+	testValAddr  = "cosmosvaloper1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm"
+)
+
+func TestTxsFieldCircuitLegitimateTx(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	delegateValue := msgDelegateValue(testFromAddr, testValAddr, coinBytes("stake", "777"))
+	tx := buildTestTx(
+		anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue),
+		anyBytes("/cosmos.staking.v1beta1.MsgDelegate", delegateValue),
+	)
+
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{
+		lastFieldAssertion(t, sendValue, 0x1a),
+		lastFieldAssertion(t, delegateValue, 0x0a),
+	})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("legitimate tx rejected: %v", err)
+	}
+}
+
+// TestTxsFieldCircuitDuplicateField tái hiện case2 trong main.go: một field
+// amount ẩn được chèn trước amount công khai.
+func TestTxsFieldCircuitDuplicateField(t *testing.T) {
+	hidden := coinBytes("uatom", "10000")
+	public := coinBytes("uatom", "4242")
+
+	var maliciousValue []byte
+	maliciousValue = appendLengthDelimitedField(maliciousValue, 1, []byte(testFromAddr))
+	maliciousValue = appendLengthDelimitedField(maliciousValue, 2, []byte(testToAddr))
+	maliciousValue = appendLengthDelimitedField(maliciousValue, 3, hidden)
+	maliciousValue = appendLengthDelimitedField(maliciousValue, 3, public)
+
+	delegateValue := msgDelegateValue(testFromAddr, testValAddr, coinBytes("stake", "777"))
+	tx := buildTestTx(
+		anyBytes("/cosmos.bank.v1beta1.MsgSend", maliciousValue),
+		anyBytes("/cosmos.staking.v1beta1.MsgDelegate", delegateValue),
+	)
+
+	hiddenOffset := fieldOffsets(t, maliciousValue, 0x1a)[0]
+	attack := testAssertion{
+		Key:         0x1a,
+		Value:       hidden,
+		FieldOffset: hiddenOffset,
+		MsgValueLen: len(maliciousValue),
+	}
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{
+		attack,
+		lastFieldAssertion(t, delegateValue, 0x0a),
+	})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a field that is shadowed by a later occurrence")
+	}
+
+	// Occurrence cuối cùng là giá trị mà decoder sử dụng nên vẫn chứng minh được.
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{
+		lastFieldAssertion(t, maliciousValue, 0x1a),
+		lastFieldAssertion(t, delegateValue, 0x0a),
+	})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("last occurrence rejected: %v", err)
+	}
+}
+
+// TestTxsFieldCircuitDecoyBody: TxRaw [body mồi][body thật][auth_info][sig]
+// qua được kiểm tra ADR-027 và decoder dùng body thật (body_bytes cuối cùng),
+// nên circuit không được chứng minh field của body mồi đứng đầu tx.
+func TestTxsFieldCircuitDecoyBody(t *testing.T) {
+	decoyValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	realValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "10000"))
+	decoyBody := appendLengthDelimitedField(nil, 1, anyBytes("/cosmos.bank.v1beta1.MsgSend", decoyValue))
+	realTx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", realValue))
+	assertions := []testAssertion{lastFieldAssertion(t, decoyValue, 0x1a)}
+
+	tx := append(appendLengthDelimitedField(nil, 1, decoyBody), realTx...)
+	circuit, assignment := buildTestCircuit(t, tx, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a decoy body_bytes shadowed by a later one")
+	}
+
+	hashCircuit := NewTxHashFieldCircuit(len(tx), circuit.msgConfigs)
+	hashAssignment := NewTxHashFieldCircuit(len(tx), circuit.msgConfigs)
+	hashAssignment.TxBytes = assignment.PublicTxBytes
+	hashAssignment.Msgs = assignment.Msgs
+	txHash := sha256.Sum256(tx)
+	for i := range txHash {
+		hashAssignment.TxHash[i] = uints.NewU8(txHash[i])
+	}
+	if err := test.IsSolved(hashCircuit, hashAssignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("tx hash circuit accepted a decoy body_bytes")
+	}
+
+	// Cùng body nhưng là body_bytes duy nhất thì chứng minh được.
+	tx = buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", decoyValue))
+	circuit, assignment = buildTestCircuit(t, tx, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("single body_bytes rejected: %v", err)
+	}
+
+	// [body][sig][auth_info]: decoder của SDK từ chối tag giảm dần (ADR-027).
+	_, bodyEnd := readLengthDelimited(t, tx, 1)
+	_, authEnd := readLengthDelimited(t, tx, bodyEnd+1)
+	reordered := append(append(append([]byte(nil), tx[:bodyEnd]...), tx[authEnd:]...), tx[bodyEnd:authEnd]...)
+	circuit, assignment = buildTestCircuit(t, reordered, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted TxRaw fields out of ADR-027 order")
+	}
+
+	// TxRaw không có field nào ngoài 1, 2, 3.
+	tx = appendLengthDelimitedField(tx, 4, []byte("x"))
+	circuit, assignment = buildTestCircuit(t, tx, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted an unknown TxRaw field")
+	}
+}
+
+// TestTxsFieldCircuitTrailingAnyValue: Any có thêm một value sau value được
+// chứng minh thì decoder dùng value sau, nên circuit phải từ chối.
+func TestTxsFieldCircuitTrailingAnyValue(t *testing.T) {
+	shown := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	hidden := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "10000"))
+	msg := appendLengthDelimitedField(anyBytes("/cosmos.bank.v1beta1.MsgSend", shown), 2, hidden)
+	tx := buildTestTx(msg)
+
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{lastFieldAssertion(t, shown, 0x1a)})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a value shadowed by a trailing Any.value")
+	}
+}
+
+// TestTxsFieldCircuitFieldBoundary kiểm tra FieldOffset không thể trỏ vào giữa
+// payload của field khác dù payload đó chứa byte giống một field hợp lệ.
+func TestTxsFieldCircuitFieldBoundary(t *testing.T) {
+	fake := appendLengthDelimitedField(nil, 1, []byte("forged-sender"))
+	payload := append([]byte(`{"memo":"`), fake...)
+	payload = append(payload, []byte(`"}`)...)
+
+	var execValue []byte
+	execValue = appendLengthDelimitedField(execValue, 1, []byte(testFromAddr))
+	execValue = appendLengthDelimitedField(execValue, 2, []byte(testToAddr))
+	execValue = appendLengthDelimitedField(execValue, 3, payload)
+	tx := buildTestTx(anyBytes("/cosmwasm.wasm.v1.MsgExecuteContract", execValue))
+
+	payloadOffset := fieldOffsets(t, execValue, 0x1a)[0]
+	forged := testAssertion{
+		Key:         0x0a,
+		Value:       []byte("forged-sender"),
+		FieldOffset: payloadOffset + 2 + len(`{"memo":"`),
+		MsgValueLen: len(execValue),
+	}
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{forged})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a field offset inside another field's payload")
+	}
+
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{lastFieldAssertion(t, execValue, 0x0a)})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("real sender field rejected: %v", err)
+	}
+}
+
+// TestTxsFieldCircuitWireTypeMismatch: cùng field number xuất hiện lại với
+// wire type khác thì decoder từ chối message, nên field trước đó không được
+// coi là occurrence cuối.
+func TestTxsFieldCircuitWireTypeMismatch(t *testing.T) {
+	value := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	assertion := lastFieldAssertion(t, value, 0x1a)
+	value = appendVarintField(value, 3, 5)
+	assertion.MsgValueLen = len(value)
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", value))
+
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a field repeated later with another wire type")
+	}
+}
+
+func TestTxsFieldCircuitVarintFields(t *testing.T) {
+	// MsgTransfer-like value: các field varint xen giữa các field length-delimited.
+	var value []byte
+	value = appendLengthDelimitedField(value, 1, []byte("transfer"))
+	value = appendVarintField(value, 2, 300)
+	value = appendLengthDelimitedField(value, 3, coinBytes("uatom", "1"))
+	value = appendVarintField(value, 4, 1_700_000)
+	value = appendLengthDelimitedField(value, 5, []byte(testToAddr))
+	tx := buildTestTx(anyBytes("/ibc.applications.transfer.v1.MsgTransfer", value))
+
+	assertion := lastFieldAssertion(t, value, 0x2a)
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("message with varint fields rejected: %v", err)
+	}
+
+	// 5 field nhưng chỉ cho phép duyệt 4 => phần đuôi không được kiểm tra.
+	assertion.MaxFields = 4
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{assertion})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a message with more fields than MaxFields")
+	}
+}
+
+func TestTxsFieldCircuitVarintAssertion(t *testing.T) {
+	const largeTimeout = uint64(1) << 40
+	const largeSequence = uint64(1)<<63 + 12345
+
+	var value []byte
+	value = appendLengthDelimitedField(value, 1, []byte("transfer"))
+	value = appendVarintField(value, 2, largeTimeout)
+	value = appendLengthDelimitedField(value, 3, coinBytes("uatom", "1"))
+	value = appendVarintField(value, 4, largeSequence)
+	tx := buildTestTx(anyBytes("/ibc.applications.transfer.v1.MsgTransfer", value))
+
+	for _, key := range []byte{0x10, 0x20} {
+		assertion := varintFieldAssertion(t, value, key)
+		circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("varint field %#x = %d rejected: %v", key, assertion.Varint, err)
+		}
+
+		assertion.Varint++
+		circuit, assignment = buildTestCircuit(t, tx, []testAssertion{assertion})
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatalf("circuit accepted wrong value for varint field %#x", key)
+		}
+	}
+
+	// Field length-delimited không được chứng minh như varint.
+	assertion := varintFieldAssertion(t, value, 0x10)
+	assertion.Key = 0x0a
+	assertion.FieldOffset = 0
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a length-delimited field as varint")
+	}
+}
+
+func TestTxsFieldCircuitVarintNonCanonical(t *testing.T) {
+	// 5 được mã hoá thừa byte: 0x85 0x00.
+	var value []byte
+	value = appendLengthDelimitedField(value, 1, []byte("transfer"))
+	value = append(value, 0x10, 0x85, 0x00)
+	tx := buildTestTx(anyBytes("/ibc.applications.transfer.v1.MsgTransfer", value))
+
+	assertion := testAssertion{
+		Key:         0x10,
+		FieldOffset: len(value) - 3,
+		MsgValueLen: len(value),
+		VarintField: true,
+		Varint:      5,
+	}
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a non-canonical varint")
+	}
+}
+
+func TestTxsFieldCircuitMaxLen(t *testing.T) {
+	const maxTxLen = 640
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+	shortTx := buildTestTxWithMemo("short", anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	longTx := buildTestTxWithMemo(strings.Repeat("m", 200), anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+
+	// Cùng một circuit (cùng proving key) cho các tx có độ dài khác nhau.
+	for _, tx := range [][]byte{shortTx, longTx} {
+		circuit, assignment := buildTestCircuitMaxLen(t, maxTxLen, tx, assertions)
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("tx of %d bytes rejected: %v", len(tx), err)
+		}
+	}
+
+	circuit, assignment := buildTestCircuitMaxLen(t, maxTxLen, shortTx, assertions)
+	assignment.PublicTxBytes[len(shortTx)] = 0x0a
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted non-zero padding")
+	}
+
+	// TxLen cắt ngang TxBody: body không còn nằm trong tx thật.
+	circuit, assignment = buildTestCircuitMaxLen(t, maxTxLen, shortTx, assertions)
+	body, _ := readLengthDelimited(t, shortTx, 1)
+	assignment.TxLen = len(body)
+	for i := len(body); i < len(shortTx); i++ {
+		assignment.PublicTxBytes[i] = 0
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted TxLen shorter than the tx body")
+	}
+
+	circuit, assignment = buildTestCircuitMaxLen(t, maxTxLen, shortTx, assertions)
+	assignment.TxLen = maxTxLen + 1
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted TxLen above MaxTxLen")
+	}
+}
+
+func TestTxsFieldCircuitVariableFieldValueLen(t *testing.T) {
+	const (
+		maxTxLen    = 512
+		maxValueLen = 48
+	)
+
+	// Một circuit chứng minh coin amount với độ dài bất kỳ <= maxValueLen.
+	var circuit *TxsFieldCircuit
+	for _, amount := range []string{"1", "4242", "123456789012345678901234567890"} {
+		sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", amount))
+		tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+
+		assertion := lastFieldAssertion(t, sendValue, 0x1a)
+		assertion.MsgValueLen = 0
+		assertion.MaxValueLen = maxValueLen
+
+		var assignment *TxsFieldCircuit
+		circuit, assignment = buildTestCircuitMaxLen(t, maxTxLen, tx, []testAssertion{assertion})
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("amount %s rejected: %v", amount, err)
+		}
+	}
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertion := lastFieldAssertion(t, sendValue, 0x1a)
+	assertion.MsgValueLen = 0
+	assertion.MaxValueLen = maxValueLen
+
+	_, assignment := buildTestCircuitMaxLen(t, maxTxLen, tx, []testAssertion{assertion})
+	assignment.Msgs[0].Field.Value[len(assertion.Value)] = 0x30
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted non-zero field value padding")
+	}
+
+	// Len ngắn hơn độ dài thật: prefix của value không phải là value.
+	_, assignment = buildTestCircuitMaxLen(t, maxTxLen, tx, []testAssertion{assertion})
+	assignment.Msgs[0].Field.Len = len(assertion.Value) - 1
+	assignment.Msgs[0].Field.Value[len(assertion.Value)-1] = 0
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a truncated field value")
+	}
+}
+
+func TestTxsFieldCircuitNestedPath(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	delegateValue := msgDelegateValue(testFromAddr, testValAddr, coinBytes("stake", "777"))
+	tx := buildTestTx(
+		anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue),
+		anyBytes("/cosmos.staking.v1beta1.MsgDelegate", delegateValue),
+	)
+
+	// MsgSend.amount.denom (3 → 1) và MsgDelegate.amount.amount (3 → 2).
+	denom := nestedFieldAssertion(t, sendValue, 0x0a, 0x1a)
+	amount := nestedFieldAssertion(t, delegateValue, 0x12, 0x1a)
+	if string(denom.Value) != "uatom" || string(amount.Value) != "777" {
+		t.Fatalf("unexpected nested values %q, %q", denom.Value, amount.Value)
+	}
+
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{denom, amount})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("nested path rejected: %v", err)
+	}
+
+	// Path trỏ vào from_address (string) thay vì amount.
+	wrongPath := denom
+	wrongPath.Path = []testPathStep{{Key: 0x0a, Offset: 0}}
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{wrongPath, amount})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a path through a non-message field")
+	}
+}
+
+func TestTxsFieldCircuitNestedPathDuplicateParent(t *testing.T) {
+	// MsgDelegate.amount là Coin singular lặp lại: decoder merge hai occurrence,
+	// nên không occurrence nào được dùng làm bước trên đường dẫn.
+	hidden := coinBytes("stake", "10000")
+	maliciousValue := msgDelegateValue(testFromAddr, testValAddr, hidden)
+	maliciousValue = appendLengthDelimitedField(maliciousValue, 3, coinBytes("stake", "777"))
+	tx := buildTestTx(anyBytes("/cosmos.staking.v1beta1.MsgDelegate", maliciousValue))
+
+	// Chứng minh amount.amount của occurrence đầu tiên (bị che bởi occurrence sau).
+	attack := nestedFieldAssertion(t, maliciousValue, 0x12, 0x1a)
+	attack.Path[0].Offset = fieldOffsets(t, maliciousValue, 0x1a)[0]
+	attack.Value = []byte("10000")
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{attack})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a path through a shadowed sub-message")
+	}
+
+	// Occurrence cuối cũng bị từ chối: giá trị decoder dùng là bản merge của
+	// cả hai, không phải payload cuối (vd. field repeated bên trong bị nối).
+	attack = nestedFieldAssertion(t, maliciousValue, 0x12, 0x1a)
+	attack.Value = []byte("777")
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{attack})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a path through a duplicated singular sub-message")
+	}
+
+	// Index khác 0 không có nghĩa với bước singular.
+	attack = nestedFieldAssertion(t, maliciousValue, 0x12, 0x1a)
+	attack.Path[0].Index = 1
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{attack})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted an element index on a singular path step")
+	}
+
+	// Chứng minh chính amount (Coin) làm field: payload cuối không phải giá trị
+	// decoder thấy, nên MessageField đòi amount xuất hiện đúng một lần.
+	attack = lastFieldAssertion(t, maliciousValue, 0x1a)
+	attack.MessageField = true
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{attack})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a duplicated singular sub-message as the field")
+	}
+
+	single := msgDelegateValue(testFromAddr, testValAddr, hidden)
+	control := lastFieldAssertion(t, single, 0x1a)
+	control.MessageField = true
+	circuit, assignment = buildTestCircuit(t, buildTestTx(anyBytes("/cosmos.staking.v1beta1.MsgDelegate", single)), []testAssertion{control})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("single amount rejected: %v", err)
+	}
+}
+
+// TestTxsFieldCircuitRepeatedPath: MsgSend.amount là repeated Coin, mỗi coin là
+// một phần tử chứ không che nhau; Index cho biết phần tử nào được chứng minh.
+func TestTxsFieldCircuitRepeatedPath(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "10000"), coinBytes("ustake", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	coinOffsets := fieldOffsets(t, sendValue, 0x1a)
+
+	// amount[k].denom
+	denomAt := func(k int) testAssertion {
+		coin, _ := readLengthDelimited(t, sendValue, coinOffsets[k]+1)
+		assertion := lastFieldAssertion(t, coin, 0x0a)
+		assertion.MsgValueLen = len(sendValue)
+		assertion.MaxValueLen = 8
+		assertion.Path = []testPathStep{{Key: 0x1a, Offset: coinOffsets[k], Repeated: true, Index: k}}
+		return assertion
+	}
+	for k, want := range []string{"uatom", "ustake"} {
+		assertion := denomAt(k)
+		if string(assertion.Value) != want {
+			t.Fatalf("amount[%d].denom = %q, want %q", k, assertion.Value, want)
+		}
+		circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("amount[%d].denom rejected: %v", k, err)
+		}
+	}
+
+	// Khẳng định coin đầu tiên là amount[1].
+	attack := denomAt(0)
+	attack.Path[0].Index = 1
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{attack})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a wrong element index")
+	}
+}
+
+// TestTxsFieldCircuitRepeatedField chứng minh từng coin của MsgSend.amount như
+// một field repeated.
+func TestTxsFieldCircuitRepeatedField(t *testing.T) {
+	coins := [][]byte{coinBytes("uatom", "10000"), coinBytes("uatom", "4242")}
+	sendValue := msgSendValue(testFromAddr, testToAddr, coins...)
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	offsets := fieldOffsets(t, sendValue, 0x1a)
+
+	for k, coin := range coins {
+		assertion := testAssertion{
+			Key:           0x1a,
+			Value:         coin,
+			FieldOffset:   offsets[k],
+			MsgValueLen:   len(sendValue),
+			RepeatedField: true,
+			Index:         k,
+		}
+		circuit, assignment := buildTestCircuit(t, tx, []testAssertion{assertion})
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("amount[%d] rejected: %v", k, err)
+		}
+
+		assertion.Index = 1 - k
+		circuit, assignment = buildTestCircuit(t, tx, []testAssertion{assertion})
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatalf("circuit accepted amount[%d] claimed as amount[%d]", k, 1-k)
+		}
+	}
+
+	// Field singular: occurrence đầu tiên vẫn bị che.
+	singular := testAssertion{Key: 0x1a, Value: coins[0], FieldOffset: offsets[0], MsgValueLen: len(sendValue)}
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{singular})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a shadowed singular field")
+	}
+}
+
+func TestTxsFieldCircuitTypeURL(t *testing.T) {
+	const (
+		sendType     = "/cosmos.bank.v1beta1.MsgSend"
+		delegateType = "/cosmos.staking.v1beta1.MsgDelegate"
+	)
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	delegateValue := msgDelegateValue(testFromAddr, testValAddr, coinBytes("stake", "777"))
+	tx := buildTestTx(anyBytes(sendType, sendValue), anyBytes(delegateType, delegateValue))
+
+	send := lastFieldAssertion(t, sendValue, 0x1a)
+	send.TypeURL = sendType
+	delegate := lastFieldAssertion(t, delegateValue, 0x0a)
+	delegate.TypeURL = delegateType
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{send, delegate})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("matching type URLs rejected: %v", err)
+	}
+
+	// Cùng sức chứa 64 cho mọi loại Msg: type URL ngắn hơn được pad 0.
+	send.MaxTypeURLLen, delegate.MaxTypeURLLen = 64, 64
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{send, delegate})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("padded type URLs rejected: %v", err)
+	}
+
+	// Khẳng định message đầu là MsgDelegate dù nó là MsgSend.
+	send.TypeURL = delegateType
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{send, delegate})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a mismatched type URL")
+	}
+
+	// Prefix của type URL thật cũng không được chấp nhận.
+	send.TypeURL = "/cosmos.bank.v1beta1.Msg"
+	circuit, assignment = buildTestCircuit(t, tx, []testAssertion{send, delegate})
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a truncated type URL")
+	}
+}
+
+// testAssertion mirrors txsFieldAssertion in main.go; BodyOffset is filled in
+// by buildTestCircuit.
+type testAssertion struct {
+	Key         byte
+	Value       []byte
+	FieldOffset int
+	MsgValueLen int
+	MaxFields   int
+	// MaxValueLen > 0 dùng MsgConfig.MaxFieldValueLen thay vì độ dài cố định.
+	MaxValueLen int
+	Path        []testPathStep
+	TypeURL     string
+	// MaxTypeURLLen mặc định là len(TypeURL).
+	MaxTypeURLLen int
+	// VarintField chứng minh field wire type 0 với giá trị Varint.
+	VarintField bool
+	Varint      uint64
+	// RepeatedField chọn phần tử thứ Index của field repeated.
+	RepeatedField bool
+	Index         int
+	// MessageField: Field là sub-message singular, phải xuất hiện đúng một lần.
+	MessageField bool
+}
+
+type testPathStep struct {
+	Key    byte
+	Offset int
+	// Repeated chọn phần tử thứ Index thay vì occurrence cuối cùng.
+	Repeated bool
+	Index    int
+}
+
+// nestedFieldAssertion đi xuống msgValue theo path (mỗi bước chọn occurrence
+// cuối cùng) rồi lấy occurrence cuối cùng của key trong sub-message đó.
+func nestedFieldAssertion(t *testing.T, msgValue []byte, key byte, path ...byte) testAssertion {
+	t.Helper()
+	region := msgValue
+	var steps []testPathStep
+	for _, stepKey := range path {
+		offsets := fieldOffsets(t, region, stepKey)
+		offset := offsets[len(offsets)-1]
+		steps = append(steps, testPathStep{Key: stepKey, Offset: offset})
+		region, _ = readLengthDelimited(t, region, offset+1)
+	}
+
+	assertion := lastFieldAssertion(t, region, key)
+	assertion.MsgValueLen = len(msgValue)
+	assertion.Path = steps
+	return assertion
+}
+
+// varintFieldAssertion lấy occurrence cuối cùng của field varint key.
+func varintFieldAssertion(t *testing.T, msgValue []byte, key byte) testAssertion {
+	t.Helper()
+	offsets := fieldOffsets(t, msgValue, key)
+	offset := offsets[len(offsets)-1]
+	value, _ := decodeVarint(t, msgValue[offset+1:])
+	return testAssertion{
+		Key:         key,
+		FieldOffset: offset,
+		MsgValueLen: len(msgValue),
+		VarintField: true,
+		Varint:      value,
+	}
+}
+
+func lastFieldAssertion(t *testing.T, msgValue []byte, key byte) testAssertion {
+	t.Helper()
+	offsets := fieldOffsets(t, msgValue, key)
+	offset := offsets[len(offsets)-1]
+	value, _ := readLengthDelimited(t, msgValue, offset+1)
+	return testAssertion{
+		Key:         key,
+		Value:       value,
+		FieldOffset: offset,
+		MsgValueLen: len(msgValue),
+	}
+}
+
+func buildTestCircuit(t *testing.T, tx []byte, assertions []testAssertion) (*TxsFieldCircuit, *TxsFieldCircuit) {
+	t.Helper()
+	configs := make([]MsgConfig, len(assertions))
+	for i, a := range assertions {
+		configs[i] = MsgConfig{
+			MaxFieldValueLen: a.MaxValueLen,
+			MsgValueLen:      a.MsgValueLen,
+			MaxFields:        a.MaxFields,
+			PathDepth:        len(a.Path),
+			MaxTypeURLLen:    a.MaxTypeURLLen,
+			VarintField:      a.VarintField,
+			RepeatedField:    a.RepeatedField,
+			MessageField:     a.MessageField,
+		}
+		for d, step := range a.Path {
+			if !step.Repeated {
+				continue
+			}
+			if configs[i].RepeatedPath == nil {
+				configs[i].RepeatedPath = make([]bool, len(a.Path))
+			}
+			configs[i].RepeatedPath[d] = true
+		}
+		if configs[i].MaxTypeURLLen == 0 {
+			configs[i].MaxTypeURLLen = len(a.TypeURL)
+		}
+		if a.MaxValueLen == 0 && !a.VarintField {
+			configs[i].FieldValueLen = len(a.Value)
+		}
+	}
+
+	bodyOffsets := messageOffsets(t, tx)
+	if len(bodyOffsets) < len(assertions) {
+		t.Fatalf("tx has %d messages, want at least %d", len(bodyOffsets), len(assertions))
+	}
+
+	assignment := NewTxsFieldCircuit(len(tx), configs)
+	for i, b := range tx {
+		assignment.PublicTxBytes[i] = b
+	}
+	assignment.TxLen = len(tx)
+	for i, a := range assertions {
+		assignment.Msgs[i].Field.Key = a.Key
+		assignment.Msgs[i].Field.Len = len(a.Value)
+		assignment.Msgs[i].Field.Varint = a.Varint
+		assignment.Msgs[i].Field.Index = a.Index
+		for j := range assignment.Msgs[i].Field.Value {
+			assignment.Msgs[i].Field.Value[j] = 0
+		}
+		for j, b := range a.Value {
+			assignment.Msgs[i].Field.Value[j] = b
+		}
+		assignment.Msgs[i].FieldOffset = a.FieldOffset
+		for j := range assignment.Msgs[i].TypeURL {
+			assignment.Msgs[i].TypeURL[j] = 0
+			if j < len(a.TypeURL) {
+				assignment.Msgs[i].TypeURL[j] = a.TypeURL[j]
+			}
+		}
+		for d, step := range a.Path {
+			assignment.Msgs[i].Path[d].Key = step.Key
+			assignment.Msgs[i].Path[d].Offset = step.Offset
+			assignment.Msgs[i].Path[d].Index = step.Index
+		}
+		assignment.Msgs[i].BodyOffset = bodyOffsets[i]
+	}
+
+	return NewTxsFieldCircuit(len(tx), configs), assignment
+}
+
+// buildTestCircuitMaxLen giống buildTestCircuit nhưng dùng circuit có sức chứa
+// maxTxLen; phần đuôi PublicTxBytes được pad 0.
+func buildTestCircuitMaxLen(t *testing.T, maxTxLen int, tx []byte, assertions []testAssertion) (*TxsFieldCircuit, *TxsFieldCircuit) {
+	t.Helper()
+	fixedCircuit, fixedAssignment := buildTestCircuit(t, tx, assertions)
+
+	assignment := NewTxsFieldCircuitMaxLen(maxTxLen, fixedCircuit.msgConfigs)
+	for i := range assignment.PublicTxBytes {
+		assignment.PublicTxBytes[i] = 0
+	}
+	copy(assignment.PublicTxBytes, fixedAssignment.PublicTxBytes)
+	assignment.TxLen = len(tx)
+	assignment.Msgs = fixedAssignment.Msgs
+
+	return NewTxsFieldCircuitMaxLen(maxTxLen, fixedCircuit.msgConfigs), assignment
+}
+
+func buildTestTx(anyMsgs ...[]byte) []byte {
+	return buildTestTxWithMemo(strings.Repeat("txs-field-demo-", 10), anyMsgs...)
+}
+
+func buildTestTxWithMemo(memo string, anyMsgs ...[]byte) []byte {
+	return buildTestTxWithFee(memo, testFee(300000, coinBytes("uatom", "5000")), anyMsgs...)
+}
+
+func buildTestTxWithFee(memo string, fee []byte, anyMsgs ...[]byte) []byte {
+	var body []byte
+	for _, msg := range anyMsgs {
+		body = appendLengthDelimitedField(body, 1, msg)
+	}
+	body = appendLengthDelimitedField(body, 2, []byte(memo))
+	return buildTestTxWithBody(body, fee)
+}
+
+// buildTestTxWithBody dựng TxRaw từ TxBody đã encode sẵn.
+func buildTestTxWithBody(body, fee []byte) []byte {
+	pubKey := make([]byte, 33)
+	pubKey[0] = 0x02
+	var signerInfo []byte
+	signerInfo = appendLengthDelimitedField(signerInfo, 1, anyBytes(
+		"/cosmos.crypto.secp256k1.PubKey",
+		appendLengthDelimitedField(nil, 1, pubKey),
+	))
+	signerInfo = appendLengthDelimitedField(signerInfo, 2,
+		appendLengthDelimitedField(nil, 1, appendVarintField(nil, 1, 1)))
+
+	var authInfo []byte
+	authInfo = appendLengthDelimitedField(authInfo, 1, signerInfo)
+	authInfo = appendLengthDelimitedField(authInfo, 2, fee)
+
+	var tx []byte
+	tx = appendLengthDelimitedField(tx, 1, body)
+	tx = appendLengthDelimitedField(tx, 2, authInfo)
+	tx = appendLengthDelimitedField(tx, 3, make([]byte, 64))
+	return tx
+}
+
+func testFee(gasLimit uint64, coins ...[]byte) []byte {
+	var fee []byte
+	for _, coin := range coins {
+		fee = appendLengthDelimitedField(fee, 1, coin)
+	}
+	return appendVarintField(fee, 2, gasLimit)
+}
+
+func msgSendValue(from, to string, coins ...[]byte) []byte {
+	var buf []byte
+	buf = appendLengthDelimitedField(buf, 1, []byte(from))
+	buf = appendLengthDelimitedField(buf, 2, []byte(to))
+	for _, coin := range coins {
+		buf = appendLengthDelimitedField(buf, 3, coin)
+	}
+	return buf
+}
+
+func msgDelegateValue(delegator, validator string, coin []byte) []byte {
+	var buf []byte
+	buf = appendLengthDelimitedField(buf, 1, []byte(delegator))
+	buf = appendLengthDelimitedField(buf, 2, []byte(validator))
+	return appendLengthDelimitedField(buf, 3, coin)
+}
+
+func coinBytes(denom, amount string) []byte {
+	var buf []byte
+	buf = appendLengthDelimitedField(buf, 1, []byte(denom))
+	return appendLengthDelimitedField(buf, 2, []byte(amount))
+}
+
+func anyBytes(typeURL string, value []byte) []byte {
+	var buf []byte
+	buf = appendLengthDelimitedField(buf, 1, []byte(typeURL))
+	return appendLengthDelimitedField(buf, 2, value)
+}
+
+func appendLengthDelimitedField(buf []byte, fieldNumber int, value []byte) []byte {
+	buf = append(buf, byte((fieldNumber<<3)|2))
+	buf = append(buf, encodeVarint(uint64(len(value)))...)
+	return append(buf, value...)
+}
+
+func appendVarintField(buf []byte, fieldNumber int, value uint64) []byte {
+	buf = append(buf, byte(fieldNumber<<3))
+	return append(buf, encodeVarint(value)...)
+}
+
+func encodeVarint(v uint64) []byte {
+	var out []byte
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func decodeVarint(t testing.TB, data []byte) (uint64, int) {
+	t.Helper()
+	var value uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		value |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	t.Fatalf("invalid varint % x", data)
+	return 0, 0
+}
+
+// readLengthDelimited đọc varint độ dài tại idx và trả về payload cùng vị trí
+// ngay sau payload.
+func readLengthDelimited(t testing.TB, data []byte, idx int) ([]byte, int) {
+	t.Helper()
+	length, n := decodeVarint(t, data[idx:])
+	start := idx + n
+	end := start + int(length)
+	if end > len(data) {
+		t.Fatalf("length-delimited field at %d overruns buffer", idx)
+	}
+	return data[start:end], end
+}
+
+// fieldOffsets trả về offset của mọi field có key trong message value (wire
+// type 0 hoặc 2).
+func fieldOffsets(t testing.TB, msgValue []byte, key byte) []int {
+	t.Helper()
+	var offsets []int
+	for idx := 0; idx < len(msgValue); {
+		if msgValue[idx] == key {
+			offsets = append(offsets, idx)
+		}
+		if msgValue[idx]&0x07 == 0 {
+			_, n := decodeVarint(t, msgValue[idx+1:])
+			idx += 1 + n
+			continue
+		}
+		_, idx = readLengthDelimited(t, msgValue, idx+1)
+	}
+	if len(offsets) == 0 {
+		t.Fatalf("field 0x%x not found", key)
+	}
+	return offsets
+}
+
+func messageOffsets(t *testing.T, tx []byte) []int {
+	t.Helper()
+	if tx[0] != 0x0a {
+		t.Fatalf("invalid body tag 0x%x", tx[0])
+	}
+	body, _ := readLengthDelimited(t, tx, 1)
+	bodyStart := 1 + len(encodeVarint(uint64(len(body))))
+
+	var offsets []int
+	for idx := 0; idx < len(body) && body[idx] == 0x0a; {
+		offsets = append(offsets, bodyStart+idx)
+		_, idx = readLengthDelimited(t, body, idx+1)
+	}
+	return offsets
+}
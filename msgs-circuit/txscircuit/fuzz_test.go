@@ -0,0 +1,804 @@
+package txscircuit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// FuzzTxsFieldCircuit đột biến tx của case1 (MsgSend + MsgDelegate), cả body
+// lẫn danh sách field của TxRaw, rồi so sánh circuit với một parser protobuf
+// tham chiếu viết độc lập trên protowire và đọc tx như decoder của SDK:
+// với mỗi khẳng định (claim) và mỗi witness thử, test.IsSolved phải thành công
+// khi và chỉ khi parser tham chiếu chấp nhận claim và witness là đúng vị trí
+// parser tìm được. Mọi bất đồng là lỗi soundness (hoặc completeness) của
+// circuit.
+//
+// ops là chuỗi lệnh 4 byte {op, a, b, c}, xem applyFuzzOps. Chạy bằng
+//
+//	go test ./txscircuit -run '^$' -fuzz FuzzTxsFieldCircuit
+func FuzzTxsFieldCircuit(f *testing.F) {
+	f.Add(false, []byte{})
+	f.Add(true, []byte{})
+	// Nhân đôi amount của MsgSend (field 3 trong value): case2.
+	f.Add(false, []byte{fuzzOpDup, 6, 2, 0})
+	// Nhân đôi value của Any: decoder dùng value thứ hai.
+	f.Add(false, []byte{fuzzOpDup, 3, 2, 0})
+	// type_url thứ hai sau value.
+	f.Add(false, []byte{fuzzOpDup, 2, 2, 0})
+	// Bước singular trên đường dẫn lặp lại (decoder merge).
+	f.Add(true, []byte{fuzzOpDup, 6, 2, 0})
+	// amount trùng field number nhưng wire type 0.
+	f.Add(false, []byte{fuzzOpDup, 6, 3, 0, fuzzOpSetKey, 9, 0, 0x18})
+	// Varint độ dài không canonical.
+	f.Add(true, []byte{fuzzOpPad, 8, 1, 0})
+	f.Add(false, []byte{fuzzOpPad, 1, 2, 0})
+	// Lật msb byte độ dài / cắt ngắn body / xoá Msg.
+	f.Add(false, []byte{fuzzOpFlip, 0, 4, 0x80})
+	f.Add(false, []byte{fuzzOpTruncate, 0, 90, 0})
+	f.Add(true, []byte{fuzzOpDelete, 11, 0, 0})
+	// TxRaw: body_bytes lặp lại (giống hệt / body mồi đứng trước body thật đã
+	// bị xoá amount), auth_info_bytes lặp lại, auth_info trước body, signature
+	// trước auth_info.
+	f.Add(false, []byte{fuzzOpRawDup, 0, 1, 0})
+	f.Add(false, []byte{fuzzOpRawDup, 0, 0, 0, fuzzOpDelete, 6, 0, 0})
+	f.Add(true, []byte{fuzzOpRawDup, 0, 0, 0, fuzzOpDup, 6, 2, 0})
+	f.Add(false, []byte{fuzzOpRawDup, 1, 2, 0})
+	f.Add(false, []byte{fuzzOpRawSwap, 0, 1, 0})
+	f.Add(false, []byte{fuzzOpRawSwap, 1, 2, 0})
+
+	f.Fuzz(func(t *testing.T, nested bool, ops []byte) {
+		if len(ops) > 32 {
+			return
+		}
+		claims := fuzzSeedClaims(nested)
+		tx := applyFuzzOps(fuzzSeedBody(), ops)
+		checkAgainstReference(t, tx, claims)
+	})
+}
+
+// fuzzClaim là public input của một MsgAssertion: Msg có TypeURL, đi xuống
+// theo Path rồi field Key có Value.
+type fuzzClaim struct {
+	TypeURL string
+	Path    []byte
+	Key     byte
+	Value   []byte
+}
+
+// fuzzWitness là các offset bí mật cho mỗi claim.
+type fuzzWitness struct {
+	BodyOffset  int
+	PathOffsets []int
+	FieldOffset int
+}
+
+func fuzzSeedClaims(nested bool) []fuzzClaim {
+	send := fuzzClaim{TypeURL: fuzzSendType, Key: 0x1a, Value: coinBytes("uatom", "4242")}
+	if nested {
+		send = fuzzClaim{TypeURL: fuzzSendType, Path: []byte{0x1a}, Key: 0x12, Value: []byte("4242")}
+	}
+	return []fuzzClaim{
+		send,
+		{TypeURL: fuzzDelegateType, Key: 0x0a, Value: []byte(testFromAddr)},
+	}
+}
+
+const (
+	fuzzSendType     = "/cosmos.bank.v1beta1.MsgSend"
+	fuzzDelegateType = "/cosmos.staking.v1beta1.MsgDelegate"
+)
+
+// checkAgainstReference thử từng claim (claim gốc và claim kẻ tấn công đọc
+// được bằng parser lỏng) với từng chiến lược witness.
+func checkAgainstReference(t *testing.T, tx []byte, seed []fuzzClaim) {
+	t.Helper()
+	if len(tx) == 0 {
+		return
+	}
+	claimSets := [][]fuzzClaim{seed}
+	if naive, ok := naiveClaims(tx, seed); ok {
+		claimSets = append(claimSets, naive)
+	}
+
+	for _, claims := range claimSets {
+		want, refErr := referenceWitness(tx, claims)
+		candidates := naiveWitnesses(tx, claims)
+		if refErr == nil {
+			candidates = append([][]fuzzWitness{want}, candidates...)
+		}
+		for _, w := range candidates {
+			expect := refErr == nil && fuzzWitnessEqual(w, want)
+			err := solveFuzzAssignment(tx, claims, w)
+			if expect && err != nil {
+				t.Fatalf("circuit rejected a claim the reference accepts\ntx=%x\nclaims=%+v\nwitness=%+v\nerr=%v", tx, claims, w, err)
+			}
+			if !expect && err == nil {
+				t.Fatalf("circuit accepted a claim the reference rejects (%v)\ntx=%x\nclaims=%+v\nwitness=%+v\nreference witness=%+v", refErr, tx, claims, w, want)
+			}
+		}
+	}
+}
+
+func fuzzWitnessEqual(a, b []fuzzWitness) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].BodyOffset != b[i].BodyOffset || a[i].FieldOffset != b[i].FieldOffset || len(a[i].PathOffsets) != len(b[i].PathOffsets) {
+			return false
+		}
+		for d := range a[i].PathOffsets {
+			if a[i].PathOffsets[d] != b[i].PathOffsets[d] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func solveFuzzAssignment(tx []byte, claims []fuzzClaim, witness []fuzzWitness) error {
+	configs := make([]MsgConfig, len(claims))
+	for i, c := range claims {
+		configs[i] = MsgConfig{
+			FieldValueLen: len(c.Value),
+			PathDepth:     len(c.Path),
+			MaxTypeURLLen: len(c.TypeURL),
+		}
+	}
+	assignment := NewTxsFieldCircuit(len(tx), configs)
+	padVariables(assignment.PublicTxBytes, tx)
+	assignment.TxLen = len(tx)
+	for i, c := range claims {
+		msg := &assignment.Msgs[i]
+		padVariables(msg.TypeURL, []byte(c.TypeURL))
+		for d, key := range c.Path {
+			msg.Path[d].Key = key
+			msg.Path[d].Index = 0
+			msg.Path[d].Offset = witness[i].PathOffsets[d]
+		}
+		msg.Field.Key = c.Key
+		padVariables(msg.Field.Value, c.Value)
+		msg.Field.Len = len(c.Value)
+		msg.Field.Varint = 0
+		msg.Field.Index = 0
+		msg.FieldOffset = witness[i].FieldOffset
+		msg.BodyOffset = witness[i].BodyOffset
+	}
+	return test.IsSolved(NewTxsFieldCircuit(len(tx), configs), assignment, ecc.BN254.ScalarField())
+}
+
+// ---------------------------------------------------------------------------
+// Parser tham chiếu.
+
+var (
+	// errReference: decoder của SDK từ chối tx hoặc đọc ra giá trị khác claim.
+	errReference = errors.New("reference parser rejects claim")
+	// errUnsupported: SDK đọc ra đúng claim nhưng tx nằm ngoài phạm vi circuit
+	// hỗ trợ, nên circuit cũng phải từ chối.
+	errUnsupported = errors.New("tx outside the circuit's scope")
+)
+
+// refField là một field protobuf; vị trí tuyệt đối trong tx.
+type refField struct {
+	Num          protowire.Number
+	Type         protowire.Type
+	Start        int
+	PayloadStart int
+	End          int
+}
+
+// refParse parse tx[start:end] như decoder protobuf. Key nhiều byte, wire type
+// khác 0/2 và varint không canonical là errUnsupported (circuit không hỗ trợ).
+func refParse(tx []byte, start, end int) ([]refField, error) {
+	var fields []refField
+	for pos := start; pos < end; {
+		data := tx[pos:end]
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("%w: tag at %d: %v", errReference, pos, protowire.ParseError(n))
+		}
+		if n != 1 {
+			return nil, fmt.Errorf("%w: multi-byte key at %d", errUnsupported, pos)
+		}
+		f := refField{Num: num, Type: typ, Start: pos}
+		switch typ {
+		case protowire.VarintType:
+			v, m := protowire.ConsumeVarint(data[n:])
+			if m < 0 {
+				return nil, fmt.Errorf("%w: varint at %d", errReference, pos)
+			}
+			if m != protowire.SizeVarint(v) {
+				return nil, fmt.Errorf("%w: varint at %d", errUnsupported, pos)
+			}
+			f.End = pos + n + m
+		case protowire.BytesType:
+			l, m := protowire.ConsumeVarint(data[n:])
+			if m < 0 {
+				return nil, fmt.Errorf("%w: length at %d", errReference, pos)
+			}
+			if m != protowire.SizeVarint(l) {
+				return nil, fmt.Errorf("%w: length at %d", errUnsupported, pos)
+			}
+			if l > uint64(len(data)-n-m) {
+				return nil, fmt.Errorf("%w: field at %d overruns its parent", errReference, pos)
+			}
+			f.PayloadStart = pos + n + m
+			f.End = f.PayloadStart + int(l)
+		default:
+			return nil, fmt.Errorf("%w: wire type %d at %d", errUnsupported, typ, pos)
+		}
+		fields = append(fields, f)
+		pos = f.End
+	}
+	return fields, nil
+}
+
+// refLast trả về occurrence cuối cùng của key ("last field wins"); cùng field
+// number với wire type khác làm decoder lỗi.
+func refLast(fields []refField, key byte) (refField, error) {
+	num, typ := protowire.Number(key>>3), protowire.Type(key&7)
+	found := -1
+	for i, f := range fields {
+		if f.Num != num {
+			continue
+		}
+		if f.Type != typ {
+			return refField{}, fmt.Errorf("%w: field %d mixes wire types", errReference, num)
+		}
+		found = i
+	}
+	if found < 0 {
+		return refField{}, fmt.Errorf("%w: field %d not found", errReference, num)
+	}
+	return fields[found], nil
+}
+
+// refOnly trả về occurrence duy nhất của sub-message key: sub-message singular
+// lặp lại được decoder merge nên không có payload nào là giá trị được dùng.
+func refOnly(fields []refField, key byte) (refField, error) {
+	f, err := refLast(fields, key)
+	if err != nil {
+		return refField{}, err
+	}
+	for _, other := range fields {
+		if other.Num == f.Num && other.Start != f.Start {
+			return refField{}, fmt.Errorf("%w: sub-message %d occurs more than once", errUnsupported, f.Num)
+		}
+	}
+	return f, nil
+}
+
+// referenceWitness kiểm tra claims trên tx và trả về witness duy nhất hợp lệ:
+// claims phải khớp giá trị decoder của SDK đọc ra (checkSDKClaims), rồi tx phải
+// nằm trong phạm vi TxsFieldCircuit không có AuthInfo (scopeWitness).
+func referenceWitness(tx []byte, claims []fuzzClaim) ([]fuzzWitness, error) {
+	if err := checkSDKClaims(tx, claims); err != nil {
+		return nil, err
+	}
+	return scopeWitness(tx, claims)
+}
+
+// checkSDKClaims decode tx như SDK, độc lập với bố cục mà circuit giả định:
+// TxRaw chỉ có field 1-3 với tag không giảm (ADR-027) và dùng body_bytes,
+// auth_info_bytes cuối cùng; messages là mọi field 1 của TxBody; field
+// singular lặp lại thì occurrence cuối thắng, còn sub-message singular trên
+// Path lặp lại được merge (tương đương parse phần nối các payload). Value của
+// claim so sánh dạng bytes.
+func checkSDKClaims(tx []byte, claims []fuzzClaim) error {
+	rawFields, err := refParse(tx, 0, len(tx))
+	if err != nil {
+		return err
+	}
+	for i, f := range rawFields {
+		if f.Type != protowire.BytesType || f.Num < 1 || f.Num > 3 {
+			return fmt.Errorf("%w: TxRaw field %d", errReference, f.Num)
+		}
+		if i > 0 && f.Num < rawFields[i-1].Num {
+			return fmt.Errorf("%w: TxRaw fields out of ADR-027 order", errReference)
+		}
+	}
+	body, err := refLast(rawFields, 0x0a)
+	if err != nil {
+		return err
+	}
+	bodyFields, err := refParse(tx, body.PayloadStart, body.End)
+	if err != nil {
+		return err
+	}
+	var msgs []refField
+	for _, f := range bodyFields {
+		if f.Num != 1 {
+			continue
+		}
+		if f.Type != protowire.BytesType {
+			return fmt.Errorf("%w: TxBody.messages has wire type %d", errReference, f.Type)
+		}
+		msgs = append(msgs, f)
+	}
+	if len(msgs) != len(claims) {
+		return fmt.Errorf("%w: body has %d messages", errReference, len(msgs))
+	}
+
+	for i, claim := range claims {
+		anyFields, err := refParse(tx, msgs[i].PayloadStart, msgs[i].End)
+		if err != nil {
+			return err
+		}
+		typeURL, err := refLast(anyFields, 0x0a)
+		if err != nil {
+			return err
+		}
+		if string(tx[typeURL.PayloadStart:typeURL.End]) != claim.TypeURL {
+			return fmt.Errorf("%w: msg %d type URL", errReference, i)
+		}
+		value, err := refLast(anyFields, 0x12)
+		if err != nil {
+			return err
+		}
+
+		region := tx[value.PayloadStart:value.End]
+		for _, key := range claim.Path {
+			fields, err := refParse(region, 0, len(region))
+			if err != nil {
+				return err
+			}
+			if _, err := refLast(fields, key); err != nil {
+				return err
+			}
+			var merged []byte
+			for _, f := range fields {
+				if f.Num == protowire.Number(key>>3) {
+					merged = append(merged, region[f.PayloadStart:f.End]...)
+				}
+			}
+			region = merged
+		}
+		fields, err := refParse(region, 0, len(region))
+		if err != nil {
+			return err
+		}
+		target, err := refLast(fields, claim.Key)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(region[target.PayloadStart:target.End], claim.Value) {
+			return fmt.Errorf("%w: msg %d value", errReference, i)
+		}
+	}
+	return nil
+}
+
+// scopeWitness tính witness cho claims khi tx nằm trong phạm vi circuit: TxRaw
+// có body_bytes đứng đầu và là duy nhất, messages liền nhau ở đầu body, Any
+// đúng là {type_url, value} và sub-message trên Path xuất hiện đúng một lần.
+// Gọi sau checkSDKClaims, nên mọi lỗi ở đây là errUnsupported.
+func scopeWitness(tx []byte, claims []fuzzClaim) ([]fuzzWitness, error) {
+	rawFields, err := refParse(tx, 0, len(tx))
+	if err != nil {
+		return nil, err
+	}
+	if len(rawFields) > MaxTxRawFields {
+		return nil, fmt.Errorf("%w: %d TxRaw fields", errUnsupported, len(rawFields))
+	}
+	for i, f := range rawFields {
+		if (i == 0) != (f.Num == 1) {
+			return nil, fmt.Errorf("%w: body_bytes is not the first and only TxRaw field 1", errUnsupported)
+		}
+	}
+	raw := rawFields[0]
+	body, err := refParse(tx, raw.PayloadStart, raw.End)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < len(claims) {
+		return nil, fmt.Errorf("%w: body has %d fields", errUnsupported, len(body))
+	}
+	tail := body[len(claims):]
+	if len(tail) > DefaultMaxTailFields {
+		return nil, fmt.Errorf("%w: %d tail fields", errUnsupported, len(tail))
+	}
+	for _, f := range tail {
+		if f.Num == 1 {
+			return nil, fmt.Errorf("%w: extra message in body tail", errUnsupported)
+		}
+	}
+
+	out := make([]fuzzWitness, len(claims))
+	for i, claim := range claims {
+		msg := body[i]
+		if msg.Num != 1 || msg.Type != protowire.BytesType {
+			return nil, fmt.Errorf("%w: body field %d is not a message", errUnsupported, i)
+		}
+		anyFields, err := refParse(tx, msg.PayloadStart, msg.End)
+		if err != nil {
+			return nil, err
+		}
+		// Any phải đúng là {type_url, value}.
+		if len(anyFields) != 2 || tx[anyFields[0].Start] != 0x0a || tx[anyFields[1].Start] != 0x12 {
+			return nil, fmt.Errorf("%w: msg %d is not a {type_url, value} Any", errUnsupported, i)
+		}
+		if string(tx[anyFields[0].PayloadStart:anyFields[0].End]) != claim.TypeURL {
+			return nil, fmt.Errorf("%w: msg %d type URL", errUnsupported, i)
+		}
+
+		w := fuzzWitness{BodyOffset: msg.Start}
+		start, end := anyFields[1].PayloadStart, anyFields[1].End
+		for _, key := range claim.Path {
+			fields, err := refParse(tx, start, end)
+			if err != nil {
+				return nil, err
+			}
+			if len(fields) > DefaultMaxMsgFields {
+				return nil, fmt.Errorf("%w: %d fields", errUnsupported, len(fields))
+			}
+			step, err := refOnly(fields, key)
+			if err != nil {
+				return nil, err
+			}
+			w.PathOffsets = append(w.PathOffsets, step.Start-start)
+			start, end = step.PayloadStart, step.End
+		}
+		fields, err := refParse(tx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > DefaultMaxMsgFields {
+			return nil, fmt.Errorf("%w: %d fields", errUnsupported, len(fields))
+		}
+		target, err := refLast(fields, claim.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(tx[target.PayloadStart:target.End], claim.Value) {
+			return nil, fmt.Errorf("%w: msg %d value", errUnsupported, i)
+		}
+		w.FieldOffset = target.Start - start
+		out[i] = w
+	}
+	return out, nil
+}
+
+// ---------------------------------------------------------------------------
+// Parser lỏng: cách kẻ tấn công chọn offset — quét byte bằng key thay vì đi
+// theo biên field, chấp nhận varint không canonical.
+
+// naiveRegions trả về, cho mỗi Msg, vị trí entry trong body và vùng value của
+// Any theo bố cục "type_url rồi value" mà không kiểm tra gì thêm.
+func naiveRegions(tx []byte, n int) (bodyOffsets []int, starts []int, ends []int, ok bool) {
+	readLen := func(pos int) (int, int, bool) {
+		if pos >= len(tx) {
+			return 0, 0, false
+		}
+		v, m := protowire.ConsumeVarint(tx[pos:])
+		if m < 0 || v > uint64(len(tx)) {
+			return 0, 0, false
+		}
+		return int(v), m, true
+	}
+	_, m, ok := readLen(1)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	cursor := 1 + m
+	for i := 0; i < n; i++ {
+		msgLen, m, ok := readLen(cursor + 1)
+		if !ok {
+			return nil, nil, nil, false
+		}
+		dataStart := cursor + 1 + m
+		typeLen, m2, ok := readLen(dataStart + 1)
+		if !ok {
+			return nil, nil, nil, false
+		}
+		valueTag := dataStart + 1 + m2 + typeLen
+		valueLen, m3, ok := readLen(valueTag + 1)
+		if !ok {
+			return nil, nil, nil, false
+		}
+		start := valueTag + 1 + m3
+		end := min(start+valueLen, len(tx))
+		bodyOffsets = append(bodyOffsets, cursor)
+		starts = append(starts, start)
+		ends = append(ends, end)
+		cursor = dataStart + msgLen
+	}
+	return bodyOffsets, starts, ends, true
+}
+
+// naiveField quét [start, end) tìm byte key: lần đầu (last = false) hoặc lần
+// cuối.
+func naiveField(tx []byte, start, end int, key byte, last bool) (int, bool) {
+	pos := -1
+	for p := start; p < end; p++ {
+		if tx[p] == key {
+			pos = p
+			if !last {
+				break
+			}
+		}
+	}
+	return pos, pos >= 0
+}
+
+// naiveWitnesses trả về witness theo occurrence đầu và cuối (quét byte) của
+// từng bước.
+func naiveWitnesses(tx []byte, claims []fuzzClaim) [][]fuzzWitness {
+	bodyOffsets, starts, ends, ok := naiveRegions(tx, len(claims))
+	if !ok {
+		return nil
+	}
+	var out [][]fuzzWitness
+	for _, last := range []bool{false, true} {
+		w := make([]fuzzWitness, len(claims))
+		for i, claim := range claims {
+			w[i].BodyOffset = bodyOffsets[i]
+			start, end := starts[i], ends[i]
+			for _, key := range claim.Path {
+				pos, found := naiveField(tx, start, end, key, last)
+				if !found {
+					pos = start
+				}
+				w[i].PathOffsets = append(w[i].PathOffsets, pos-start)
+				if pos+1 < len(tx) {
+					if l, m := protowire.ConsumeVarint(tx[pos+1:]); m > 0 && l <= uint64(len(tx)) {
+						start, end = pos+1+m, min(pos+1+m+int(l), len(tx))
+					}
+				}
+			}
+			pos, found := naiveField(tx, start, end, claim.Key, last)
+			if !found {
+				pos = start
+			}
+			w[i].FieldOffset = pos - start
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// naiveClaims là claims với Value được đọc lại bằng parser lỏng (occurrence
+// đầu tiên), tức giá trị kẻ tấn công muốn khẳng định.
+func naiveClaims(tx []byte, seed []fuzzClaim) ([]fuzzClaim, bool) {
+	witnesses := naiveWitnesses(tx, seed)
+	if len(witnesses) == 0 {
+		return nil, false
+	}
+	_, starts, _, _ := naiveRegions(tx, len(seed))
+	claims := make([]fuzzClaim, len(seed))
+	changed := false
+	for i, claim := range seed {
+		start := starts[i]
+		for d := range claim.Path {
+			pos := start + witnesses[0][i].PathOffsets[d]
+			_, m := protowire.ConsumeVarint(tx[min(pos+1, len(tx)):])
+			if m < 0 {
+				return nil, false
+			}
+			start = pos + 1 + m
+		}
+		pos := start + witnesses[0][i].FieldOffset
+		if pos+1 >= len(tx) {
+			return nil, false
+		}
+		l, m := protowire.ConsumeVarint(tx[pos+1:])
+		if m < 0 || l == 0 || l > 64 || pos+1+m+int(l) > len(tx) {
+			return nil, false
+		}
+		claims[i] = claim
+		claims[i].Value = tx[pos+1+m : pos+1+m+int(l)]
+		changed = changed || !bytes.Equal(claims[i].Value, claim.Value)
+	}
+	return claims, changed
+}
+
+// ---------------------------------------------------------------------------
+// Đột biến.
+
+// fuzzNode là một field protobuf của TxBody; Sub != nil là sub-message.
+type fuzzNode struct {
+	Key  byte
+	Pad  int // số byte thừa làm varint độ dài / giá trị không canonical
+	Data []byte
+	Sub  []*fuzzNode
+	Val  uint64
+}
+
+func (n *fuzzNode) encode(buf []byte) []byte {
+	buf = append(buf, n.Key)
+	if n.Key&7 == 0 {
+		return appendPaddedVarint(buf, n.Val, n.Pad)
+	}
+	payload := n.Data
+	if n.Sub != nil {
+		payload = []byte{}
+		for _, child := range n.Sub {
+			payload = child.encode(payload)
+		}
+	}
+	buf = appendPaddedVarint(buf, uint64(len(payload)), n.Pad)
+	return append(buf, payload...)
+}
+
+func (n *fuzzNode) clone() *fuzzNode {
+	c := *n
+	c.Data = append([]byte(nil), n.Data...)
+	if n.Sub != nil {
+		c.Sub = make([]*fuzzNode, len(n.Sub))
+		for i, child := range n.Sub {
+			c.Sub[i] = child.clone()
+		}
+	}
+	return &c
+}
+
+// appendPaddedVarint encode v với pad byte thừa (0x80 ... 0x00): cùng giá
+// trị nhưng không canonical.
+func appendPaddedVarint(buf []byte, v uint64, pad int) []byte {
+	buf = protowire.AppendVarint(buf, v)
+	if pad == 0 {
+		return buf
+	}
+	buf[len(buf)-1] |= 0x80
+	for i := 1; i < pad; i++ {
+		buf = append(buf, 0x80)
+	}
+	return append(buf, 0x00)
+}
+
+func fuzzLeaf(key byte, data string) *fuzzNode {
+	return &fuzzNode{Key: key, Data: []byte(data)}
+}
+
+// fuzzSeedBody là TxBody của case1. Thứ tự duyệt trước (dùng làm chỉ số node
+// trong ops): 0 body, 1 msg0, 2 type_url, 3 value, 4 from, 5 to, 6 amount,
+// 7 denom, 8 amount, 9 msg1, 10 type_url, 11 value, 12 delegator, ...
+func fuzzSeedBody() *fuzzNode {
+	coin := func(denom, amount string) *fuzzNode {
+		return &fuzzNode{Key: 0x1a, Sub: []*fuzzNode{fuzzLeaf(0x0a, denom), fuzzLeaf(0x12, amount)}}
+	}
+	anyMsg := func(typeURL string, fields ...*fuzzNode) *fuzzNode {
+		return &fuzzNode{Key: 0x0a, Sub: []*fuzzNode{
+			fuzzLeaf(0x0a, typeURL),
+			{Key: 0x12, Sub: fields},
+		}}
+	}
+	return &fuzzNode{Key: 0x0a, Sub: []*fuzzNode{
+		anyMsg(fuzzSendType, fuzzLeaf(0x0a, testFromAddr), fuzzLeaf(0x12, testToAddr), coin("uatom", "4242")),
+		anyMsg(fuzzDelegateType, fuzzLeaf(0x0a, testFromAddr), fuzzLeaf(0x12, testValAddr), coin("stake", "777")),
+		fuzzLeaf(0x12, "fuzz"),
+		{Key: 0x18, Val: 1234},
+	}}
+}
+
+const (
+	// dup: chèn bản sao node a vào cha của nó tại vị trí b.
+	fuzzOpDup = iota
+	// delete: xoá node a.
+	fuzzOpDelete
+	// pad: varint độ dài/giá trị của node a thêm 1 + b%3 byte thừa.
+	fuzzOpPad
+	// setKey: đổi key của node a thành c.
+	fuzzOpSetKey
+	// flip: tx[(a<<8|b) % bodyEnd] ^= c (0x80 khi c = 0).
+	fuzzOpFlip
+	// truncate: cắt tx còn 1 + (a<<8|b) % len(tx) byte.
+	fuzzOpTruncate
+	// rawDup: chèn bản sao field thứ a của TxRaw tại vị trí b. Bản sao của
+	// body không nhận các đột biến cấu trúc sau đó (thành body mồi hoặc body
+	// thật tuỳ vị trí).
+	fuzzOpRawDup
+	// rawSwap: đổi chỗ field thứ a và thứ b của TxRaw.
+	fuzzOpRawSwap
+	fuzzOpCount
+)
+
+// applyFuzzOps áp dụng các đột biến cấu trúc lên body và lên danh sách field
+// của TxRaw (giữ độ dài các field cha nhất quán), encode TxRaw, rồi áp dụng
+// đột biến byte.
+func applyFuzzOps(body *fuzzNode, ops []byte) []byte {
+	var authInfo []byte
+	authInfo = appendLengthDelimitedField(authInfo, 2, testFee(200000, coinBytes("uatom", "5000")))
+	txRaw := []*fuzzNode{body, {Key: 0x12, Data: authInfo}, {Key: 0x1a, Data: make([]byte, 64)}}
+
+	var byteOps [][4]byte
+	for len(ops) >= 4 {
+		op := [4]byte{ops[0] % fuzzOpCount, ops[1], ops[2], ops[3]}
+		ops = ops[4:]
+		switch op[0] {
+		case fuzzOpFlip, fuzzOpTruncate:
+			byteOps = append(byteOps, op)
+			continue
+		case fuzzOpRawDup:
+			if len(txRaw) < MaxTxRawFields+1 {
+				field := txRaw[int(op[1])%len(txRaw)].clone()
+				pos := int(op[2]) % (len(txRaw) + 1)
+				txRaw = append(txRaw[:pos], append([]*fuzzNode{field}, txRaw[pos:]...)...)
+			}
+			continue
+		case fuzzOpRawSwap:
+			i, j := int(op[1])%len(txRaw), int(op[2])%len(txRaw)
+			txRaw[i], txRaw[j] = txRaw[j], txRaw[i]
+			continue
+		}
+
+		nodes, parents := flattenFuzzNodes(body)
+		idx := int(op[1]) % len(nodes)
+		node, parent := nodes[idx], parents[idx]
+		switch op[0] {
+		case fuzzOpDup:
+			if parent == nil {
+				continue
+			}
+			pos := int(op[2]) % (len(parent.Sub) + 1)
+			parent.Sub = append(parent.Sub[:pos], append([]*fuzzNode{node.clone()}, parent.Sub[pos:]...)...)
+		case fuzzOpDelete:
+			if parent == nil {
+				continue
+			}
+			for i, child := range parent.Sub {
+				if child == node {
+					parent.Sub = append(parent.Sub[:i], parent.Sub[i+1:]...)
+					break
+				}
+			}
+		case fuzzOpPad:
+			node.Pad = 1 + int(op[2])%3
+		case fuzzOpSetKey:
+			if parent == nil || op[3]&7 == 1 || op[3]&7 >= 3 {
+				continue
+			}
+			if node.Key&7 != op[3]&7 {
+				// Đổi wire type: giữ payload dạng bytes hoặc lấy độ dài làm varint.
+				if op[3]&7 == 0 {
+					node.Val = uint64(len(node.encode(nil)))
+				} else if node.Sub == nil && node.Data == nil {
+					node.Data = protowire.AppendVarint(nil, node.Val)
+				}
+			}
+			node.Key = op[3]
+		}
+	}
+
+	tx := txRaw[0].encode(nil)
+	// Đột biến flip chỉ chạm field đầu của TxRaw (thường là body).
+	bodyEnd := len(tx)
+	for _, field := range txRaw[1:] {
+		tx = field.encode(tx)
+	}
+
+	for _, op := range byteOps {
+		pos := int(op[1])<<8 | int(op[2])
+		switch op[0] {
+		case fuzzOpFlip:
+			mask := op[3]
+			if mask == 0 {
+				mask = 0x80
+			}
+			tx[pos%bodyEnd] ^= mask
+		case fuzzOpTruncate:
+			tx = tx[:1+pos%len(tx)]
+			bodyEnd = min(bodyEnd, len(tx))
+		}
+	}
+	return tx
+}
+
+// flattenFuzzNodes liệt kê node theo thứ tự duyệt trước cùng node cha.
+func flattenFuzzNodes(root *fuzzNode) (nodes, parents []*fuzzNode) {
+	var walk func(n, parent *fuzzNode)
+	walk = func(n, parent *fuzzNode) {
+		nodes = append(nodes, n)
+		parents = append(parents, parent)
+		for _, child := range n.Sub {
+			walk(child, n)
+		}
+	}
+	walk(root, nil)
+	return nodes, parents
+}
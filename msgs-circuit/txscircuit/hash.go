@@ -0,0 +1,64 @@
+package txscircuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// TxHashFieldCircuit giống TxsFieldCircuit nhưng TxBytes là secret; public
+// input duy nhất về tx là Cosmos tx hash = SHA-256(TxRaw bytes). Verifier kiểm
+// tra "tx có hash H chứa field X" với public input kích thước cố định.
+type TxHashFieldCircuit struct {
+	TxHash  [32]uints.U8        `gnark:",public"`
+	TxBytes []frontend.Variable `gnark:",secret"`
+	Msgs    []MsgAssertion
+
+	msgConfigs  []MsgConfig
+	txIndexBits int
+}
+
+// NewTxHashFieldCircuit builds a tx-hash circuit configured for the given Tx
+// length and per-message configs.
+func NewTxHashFieldCircuit(txLen int, configs []MsgConfig) *TxHashFieldCircuit {
+	inner := NewTxsFieldCircuit(txLen, configs)
+	return &TxHashFieldCircuit{
+		TxBytes:     inner.PublicTxBytes,
+		Msgs:        inner.Msgs,
+		msgConfigs:  inner.msgConfigs,
+		txIndexBits: inner.txIndexBits,
+	}
+}
+
+func (circuit *TxHashFieldCircuit) Define(api frontend.API) error {
+	byteField, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+
+	// ByteValueOf range-check từng byte: TxBytes là secret nên không còn được
+	// verifier kiểm tra trực tiếp như PublicTxBytes.
+	txBytes := make([]uints.U8, len(circuit.TxBytes))
+	for i := range circuit.TxBytes {
+		txBytes[i] = byteField.ByteValueOf(circuit.TxBytes[i])
+	}
+
+	hasher, err := sha2.New(api)
+	if err != nil {
+		return err
+	}
+	hasher.Write(txBytes)
+	digest := hasher.Sum()
+
+	for i := 0; i < len(digest); i++ {
+		api.AssertIsEqual(circuit.TxHash[i].Val, digest[i].Val)
+	}
+
+	fields := &TxsFieldCircuit{
+		Msgs:        circuit.Msgs,
+		msgConfigs:  circuit.msgConfigs,
+		txIndexBits: circuit.txIndexBits,
+	}
+	fields.verifyTx(api, newTxBytes(api, circuit.TxBytes), len(circuit.TxBytes))
+	return nil
+}
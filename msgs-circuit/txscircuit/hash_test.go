@@ -0,0 +1,35 @@
+package txscircuit
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+func TestTxHashFieldCircuit(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+
+	fieldsCircuit, fieldsAssignment := buildTestCircuit(t, tx, assertions)
+	circuit := NewTxHashFieldCircuit(len(tx), fieldsCircuit.msgConfigs)
+	assignment := NewTxHashFieldCircuit(len(tx), fieldsCircuit.msgConfigs)
+	assignment.TxBytes = fieldsAssignment.PublicTxBytes
+	assignment.Msgs = fieldsAssignment.Msgs
+
+	txHash := sha256.Sum256(tx)
+	for i := range txHash {
+		assignment.TxHash[i] = uints.NewU8(txHash[i])
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("valid tx hash rejected: %v", err)
+	}
+
+	assignment.TxHash[0] = uints.NewU8(txHash[0] ^ 0x01)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a tx hash that does not match the tx bytes")
+	}
+}
@@ -0,0 +1,197 @@
+package txscircuit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// Prefix domain separation của simple Merkle tree CometBFT (RFC-6962):
+// leaf = SHA-256(0x00 || data), inner = SHA-256(0x01 || left || right).
+const (
+	merkleLeafPrefix  = 0x00
+	merkleInnerPrefix = 0x01
+)
+
+// TxInclusionCircuit mở rộng TxHashFieldCircuit tới mức block: ngoài các field
+// trong Msgs, nó chứng minh tx nằm ở vị trí Index trong Total tx của một block
+// có Header.DataHash = DataHash.
+//
+// DataHash là root của simple Merkle tree CometBFT trên tx hash của từng tx
+// (types.Txs.Hash): leaf thứ i là SHA-256(TxBytes), Aunts là các hash anh em
+// trên đường từ leaf tới root như merkle.Proof của CometBFT. Cây không cân bằng
+// (Total không phải lũy thừa 2) nên hướng của từng tầng phụ thuộc cả Index lẫn
+// Total; Total phải là public để Index có nghĩa, vì cùng một đường đi có thể
+// ứng với vị trí khác trong một cây có Total khác. Light client lấy DataHash từ
+// header đã tin cậy và Total từ số tx của block.
+//
+// Circuit compile cho tối đa 2^MaxDepth tx (len(Aunts) = MaxDepth); tầng thừa
+// của cây nông hơn được bỏ qua. Public input gồm DataHash, Index, Total và các
+// field trong Msgs.
+type TxInclusionCircuit struct {
+	DataHash [32]uints.U8        `gnark:",public"`
+	Index    frontend.Variable   `gnark:",public"`
+	Total    frontend.Variable   `gnark:",public"`
+	TxBytes  []frontend.Variable `gnark:",secret"`
+	Msgs     []MsgAssertion
+	// Aunts[j] là hash anh em ở tầng j tính từ root (ngược thứ tự của
+	// merkle.Proof.Aunts); tầng không dùng để 0.
+	Aunts [][32]uints.U8 `gnark:",secret"`
+
+	msgConfigs  []MsgConfig
+	txIndexBits int
+}
+
+// NewTxInclusionCircuit builds a block inclusion circuit configured for the
+// given Tx length, Merkle depth (at most 2^maxDepth txs per block) and
+// per-message configs.
+func NewTxInclusionCircuit(txLen, maxDepth int, configs []MsgConfig) *TxInclusionCircuit {
+	if maxDepth < 1 || maxDepth > 32 {
+		panic("merkle depth must be in [1, 32]")
+	}
+	inner := NewTxsFieldCircuit(txLen, configs)
+	return &TxInclusionCircuit{
+		TxBytes:     inner.PublicTxBytes,
+		Msgs:        inner.Msgs,
+		Aunts:       make([][32]uints.U8, maxDepth),
+		msgConfigs:  inner.msgConfigs,
+		txIndexBits: inner.txIndexBits,
+	}
+}
+
+// SetMerkleProof gán DataHash và merkle.Proof (Index, Total, Aunts theo thứ
+// tự của CometBFT, từ leaf lên root) vào assignment.
+func (circuit *TxInclusionCircuit) SetMerkleProof(dataHash []byte, index, total int64, aunts [][]byte) error {
+	maxDepth := len(circuit.Aunts)
+	switch {
+	case len(dataHash) != 32:
+		return fmt.Errorf("data hash is %d bytes, want 32", len(dataHash))
+	case total < 1 || index < 0 || index >= total:
+		return fmt.Errorf("invalid merkle proof position %d of %d", index, total)
+	case total > 1<<maxDepth:
+		return fmt.Errorf("%d txs do not fit a merkle tree of depth %d", total, maxDepth)
+	case len(aunts) > maxDepth:
+		return errors.New("merkle proof longer than circuit depth")
+	}
+	for i := range circuit.DataHash {
+		circuit.DataHash[i] = uints.NewU8(dataHash[i])
+	}
+	circuit.Index = index
+	circuit.Total = total
+	for j := range circuit.Aunts {
+		var aunt []byte
+		if j < len(aunts) {
+			aunt = aunts[len(aunts)-1-j]
+			if len(aunt) != 32 {
+				return fmt.Errorf("aunt %d is %d bytes, want 32", len(aunts)-1-j, len(aunt))
+			}
+		} else {
+			aunt = make([]byte, 32)
+		}
+		for i := range aunt {
+			circuit.Aunts[j][i] = uints.NewU8(aunt[i])
+		}
+	}
+	return nil
+}
+
+func (circuit *TxInclusionCircuit) Define(api frontend.API) error {
+	byteField, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+	sha256 := func(parts ...[]uints.U8) ([]uints.U8, error) {
+		hasher, err := sha2.New(api)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			hasher.Write(part)
+		}
+		return hasher.Sum(), nil
+	}
+
+	// ByteValueOf range-check từng byte như TxHashFieldCircuit.
+	txBytes := make([]uints.U8, len(circuit.TxBytes))
+	for i := range circuit.TxBytes {
+		txBytes[i] = byteField.ByteValueOf(circuit.TxBytes[i])
+	}
+	txHash, err := sha256(txBytes)
+	if err != nil {
+		return err
+	}
+	node, err := sha256([]uints.U8{uints.NewU8(merkleLeafPrefix)}, txHash)
+	if err != nil {
+		return err
+	}
+
+	// Đi từ root xuống như merkle.computeHashFromAunts: cây (n, i) tách thành
+	// cây trái k = lũy thừa 2 lớn nhất < n leaf và cây phải n-k leaf.
+	depth := len(circuit.Aunts)
+	active := make([]frontend.Variable, depth)
+	right := make([]frontend.Variable, depth)
+	n, i := circuit.Total, circuit.Index
+	// 1 <= Total <= 2^depth và 0 <= Index < Total.
+	api.ToBinary(api.Sub(n, 1), depth)
+	api.ToBinary(i, depth)
+	api.ToBinary(api.Sub(n, 1, i), depth)
+	for j := range depth {
+		active[j] = api.Sub(1, api.IsZero(api.Sub(n, 1)))
+		k := splitPoint(api, n, depth)
+		// i < n <= 2k nên i-k+2^depth nằm trong [0, 2^(depth+1)); bit cao
+		// nhất là i >= k. Tầng không dùng có n = 1, k = 0: luôn rẽ phải và
+		// giữ nguyên (1, 0).
+		right[j] = api.ToBinary(api.Add(api.Sub(i, k), 1<<depth), depth+1)[depth]
+		n = api.Select(right[j], api.Sub(n, k), k)
+		i = api.Select(right[j], api.Sub(i, k), i)
+	}
+	api.AssertIsEqual(n, 1)
+
+	for j := depth - 1; j >= 0; j-- {
+		aunt := make([]uints.U8, 32)
+		left := make([]uints.U8, 32)
+		rightNode := make([]uints.U8, 32)
+		for b := range aunt {
+			aunt[b] = byteField.ByteValueOf(circuit.Aunts[j][b].Val)
+			left[b] = uints.U8{Val: api.Select(right[j], aunt[b].Val, node[b].Val)}
+			rightNode[b] = uints.U8{Val: api.Select(right[j], node[b].Val, aunt[b].Val)}
+		}
+		parent, err := sha256([]uints.U8{uints.NewU8(merkleInnerPrefix)}, left, rightNode)
+		if err != nil {
+			return err
+		}
+		for b := range node {
+			node[b] = uints.U8{Val: api.Select(active[j], parent[b].Val, node[b].Val)}
+		}
+	}
+	for b := range node {
+		api.AssertIsEqual(circuit.DataHash[b].Val, node[b].Val)
+	}
+
+	fields := &TxsFieldCircuit{
+		Msgs:        circuit.Msgs,
+		msgConfigs:  circuit.msgConfigs,
+		txIndexBits: circuit.txIndexBits,
+	}
+	fields.verifyTx(api, newTxBytes(api, circuit.TxBytes), len(circuit.TxBytes))
+	return nil
+}
+
+// splitPoint trả về lũy thừa 2 lớn nhất nhỏ hơn n (merkle.getSplitPoint), hoặc
+// 0 khi n = 1. n phải nằm trong [1, 2^nbBits].
+func splitPoint(api frontend.API, n frontend.Variable, nbBits int) frontend.Variable {
+	bits := api.ToBinary(api.Sub(n, 1), nbBits)
+	// seen = OR các bit từ nbBits-1 xuống b; bit cao nhất của n-1 là chỗ seen
+	// chuyển từ 0 sang 1.
+	var k frontend.Variable = 0
+	var seen frontend.Variable = 0
+	for b := nbBits - 1; b >= 0; b-- {
+		next := api.Or(seen, bits[b])
+		k = api.Add(k, api.Mul(api.Sub(next, seen), 1<<b))
+		seen = next
+	}
+	return k
+}
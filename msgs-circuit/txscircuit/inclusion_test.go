@@ -0,0 +1,102 @@
+package txscircuit
+
+import (
+	"fmt"
+	"testing"
+
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+const testMerkleDepth = 3
+
+// buildInclusionTestCircuit đặt tx ở vị trí index trong một block total tx
+// (các tx còn lại là byte tùy ý) và gán merkle proof do CometBFT tạo.
+func buildInclusionTestCircuit(t *testing.T, tx []byte, assertions []testAssertion, index, total int) (*TxInclusionCircuit, *TxInclusionCircuit) {
+	t.Helper()
+	fieldsCircuit, fieldsAssignment := buildTestCircuit(t, tx, assertions)
+
+	txs := make(cmttypes.Txs, total)
+	for i := range txs {
+		txs[i] = cmttypes.Tx(fmt.Sprintf("other tx %d", i))
+	}
+	txs[index] = tx
+	proof := txs.Proof(index)
+	if err := proof.Validate(txs.Hash()); err != nil {
+		t.Fatal(err)
+	}
+
+	circuit := NewTxInclusionCircuit(len(tx), testMerkleDepth, fieldsCircuit.msgConfigs)
+	assignment := NewTxInclusionCircuit(len(tx), testMerkleDepth, fieldsCircuit.msgConfigs)
+	assignment.TxBytes = fieldsAssignment.PublicTxBytes
+	assignment.Msgs = fieldsAssignment.Msgs
+	if err := assignment.SetMerkleProof(txs.Hash(), proof.Proof.Index, proof.Proof.Total, proof.Proof.Aunts); err != nil {
+		t.Fatal(err)
+	}
+	return circuit, assignment
+}
+
+func TestTxInclusionCircuit(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+
+	// Cây cân bằng, không cân bằng, một leaf và đầy 2^depth leaf.
+	for _, pos := range []struct{ index, total int }{{0, 1}, {1, 2}, {2, 3}, {0, 5}, {4, 5}, {5, 7}, {7, 8}} {
+		circuit, assignment := buildInclusionTestCircuit(t, tx, assertions, pos.index, pos.total)
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("tx %d of %d rejected: %v", pos.index, pos.total, err)
+		}
+	}
+}
+
+func TestTxInclusionCircuitRejects(t *testing.T) {
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+
+	tests := []struct {
+		name   string
+		tamper func(*TxInclusionCircuit)
+	}{
+		{"data hash", func(a *TxInclusionCircuit) { a.DataHash[31] = uints.NewU8(0) }},
+		// Tx 2 của 3 chỉ có một aunt (Aunts[0]); Aunts[1:] là padding.
+		{"aunt", func(a *TxInclusionCircuit) { a.Aunts[0][0] = uints.NewU8(0) }},
+		{"index", func(a *TxInclusionCircuit) { a.Index = 1 }},
+		{"total", func(a *TxInclusionCircuit) { a.Total = 4 }},
+		{"index out of range", func(a *TxInclusionCircuit) { a.Index = 3 }},
+		{"tx byte", func(a *TxInclusionCircuit) { a.TxBytes[len(tx)-1] = tx[len(tx)-1] ^ 1 }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			circuit, assignment := buildInclusionTestCircuit(t, tx, assertions, 2, 3)
+			tc.tamper(assignment)
+			if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+				t.Fatal("circuit accepted a tampered inclusion proof")
+			}
+		})
+	}
+}
+
+func TestSetMerkleProofErrors(t *testing.T) {
+	circuit := NewTxInclusionCircuit(8, testMerkleDepth, nil)
+	hash := make([]byte, 32)
+	for _, tc := range []struct {
+		name         string
+		hash         []byte
+		index, total int64
+		aunts        [][]byte
+	}{
+		{"short data hash", hash[:31], 0, 1, nil},
+		{"index >= total", hash, 3, 3, nil},
+		{"too many txs", hash, 0, 1<<testMerkleDepth + 1, nil},
+		{"long proof", hash, 0, 2, [][]byte{hash, hash, hash, hash}},
+		{"short aunt", hash, 0, 2, [][]byte{hash[:16]}},
+	} {
+		if err := circuit.SetMerkleProof(tc.hash, tc.index, tc.total, tc.aunts); err == nil {
+			t.Errorf("%s: accepted", tc.name)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package txscircuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+)
+
+// txBytes là tx cùng bảng lookup log-derivative để đọc tx[idx] với idx là
+// biến. Mỗi lần đọc tốn O(1) constraint (khấu hao), thay vì mux O(n) trên cả
+// tx; bảng được dựng một lần cho mỗi tx trong Define.
+type txBytes struct {
+	vars  []frontend.Variable
+	table logderivlookup.Table
+}
+
+func newTxBytes(api frontend.API, tx []frontend.Variable) *txBytes {
+	table := logderivlookup.New(api)
+	for i := range tx {
+		table.Insert(tx[i])
+	}
+	// Đuôi 0 cho các byte varint đọc thử vượt quá cuối tx (xem decodeVarintN).
+	for i := 0; i < MaxVarintLen-1; i++ {
+		table.Insert(0)
+	}
+	return &txBytes{vars: tx, table: table}
+}
+
+// selectByteAt selects tx[idx] via the lookup table.
+// Lookup chỉ thành công với 0 <= idx < len(tx); maxIdx là hằng số (độ dài cố
+// định) hoặc biến (TxLen - 1 ở chế độ MaxTxLen).
+func selectByteAt(api frontend.API, tx *txBytes, idx frontend.Variable, maxIdx frontend.Variable) frontend.Variable {
+	assertLessOrEqual(api, idx, maxIdx, bitsFor(len(tx.vars))+1)
+	return tx.table.Lookup(idx)[0]
+}
@@ -0,0 +1,155 @@
+package txscircuit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test/unsafekzg"
+)
+
+// Backend là proof system dùng để prove TxsFieldCircuit. Cùng một circuit (và
+// ShapeKey) prove được bằng cả hai backend, nhưng CS/PK/VK thì khác nhau.
+type Backend string
+
+const (
+	// BackendGroth16 compile bằng r1cs.NewBuilder; setup riêng cho từng shape
+	// circuit.
+	BackendGroth16 Backend = "groth16"
+	// BackendPlonk compile bằng scs.NewBuilder; setup từ SRS KZG dùng chung
+	// (universal), không cần ceremony cho từng circuit.
+	BackendPlonk Backend = "plonk"
+)
+
+// ParseBackend đọc tên backend ("groth16" hoặc "plonk").
+func ParseBackend(name string) (Backend, error) {
+	switch backend := Backend(name); backend {
+	case BackendGroth16, BackendPlonk:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown backend %q (want %s or %s)", name, BackendGroth16, BackendPlonk)
+	}
+}
+
+// PlonkArtifacts là kết quả compile + PLONK setup của một shape circuit.
+type PlonkArtifacts struct {
+	CS constraint.ConstraintSystem
+	PK plonk.ProvingKey
+	VK plonk.VerifyingKey
+}
+
+// PlonkPath là thư mục chứa artifact PLONK của key, tách khỏi artifact
+// Groth16 của cùng shape.
+func (s *ArtifactStore) PlonkPath(key string) string {
+	return filepath.Join(s.curveDir(), string(BackendPlonk), key)
+}
+
+// LoadOrSetupPlonk giống LoadOrSetup nhưng cho PLONK: compile bằng
+// scs.NewBuilder và setup với SRS của unsafekzg như gnark/cmd/plonk.go.
+//
+// unsafekzg biết toxic waste nên chỉ dùng để thử nghiệm; production phải nạp
+// SRS từ một ceremony (vd. Aztec Ignition) thay cho unsafekzg.NewSRS.
+func (s *ArtifactStore) LoadOrSetupPlonk(circuit *TxsFieldCircuit) (artifacts *PlonkArtifacts, cached bool, err error) {
+	key := circuit.ShapeKey()
+	artifacts, err = s.LoadPlonk(key)
+	if err == nil {
+		return artifacts, true, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	cs, err := frontend.Compile(s.Curve.ScalarField(), scs.NewBuilder, circuit)
+	if err != nil {
+		return nil, false, fmt.Errorf("compile circuit: %w", err)
+	}
+	srs, srsLagrange, err := unsafekzg.NewSRS(cs)
+	if err != nil {
+		return nil, false, fmt.Errorf("srs: %w", err)
+	}
+	pk, vk, err := plonk.Setup(cs, srs, srsLagrange)
+	if err != nil {
+		return nil, false, fmt.Errorf("setup: %w", err)
+	}
+	artifacts = &PlonkArtifacts{CS: cs, PK: pk, VK: vk}
+	if err := saveEntry(s.PlonkPath(key), cs, pk, vk); err != nil {
+		return nil, false, err
+	}
+	return artifacts, false, nil
+}
+
+// LoadPlonk đọc artifact PLONK của key; lỗi bọc os.ErrNotExist khi key chưa
+// có trong store.
+func (s *ArtifactStore) LoadPlonk(key string) (*PlonkArtifacts, error) {
+	dir := s.PlonkPath(key)
+	cs, err := readPlonkConstraintSystem(s.Curve, filepath.Join(dir, circuitFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read circuit: %w", err)
+	}
+	pk, err := readPlonkProvingKey(s.Curve, filepath.Join(dir, provingKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read proving key: %w", err)
+	}
+	vk, err := readPlonkVerifyingKey(s.Curve, filepath.Join(dir, verifyingKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read verifying key: %w", err)
+	}
+	return &PlonkArtifacts{CS: cs, PK: pk, VK: vk}, nil
+}
+
+// LoadPlonkVerifyingKey chỉ đọc verifying key PLONK của key.
+func (s *ArtifactStore) LoadPlonkVerifyingKey(key string) (plonk.VerifyingKey, error) {
+	vk, err := readPlonkVerifyingKey(s.Curve, filepath.Join(s.PlonkPath(key), verifyingKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read verifying key: %w", err)
+	}
+	return vk, nil
+}
+
+func readPlonkVerifyingKey(curve ecc.ID, path string) (plonk.VerifyingKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vk := plonk.NewVerifyingKey(curve)
+	if _, err := vk.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+func readPlonkProvingKey(curve ecc.ID, path string) (plonk.ProvingKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pk := plonk.NewProvingKey(curve)
+	if _, err := pk.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+func readPlonkConstraintSystem(curve ecc.ID, path string) (constraint.ConstraintSystem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cs := plonk.NewCS(curve)
+	if _, err := cs.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
@@ -0,0 +1,178 @@
+package txscircuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_emulated"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/signature/ecdsa"
+)
+
+// secp256k1SignatureLen là độ dài chữ ký secp256k1 trong TxRaw.signatures
+// (r || s, mỗi phần 32 byte big-endian).
+const secp256k1SignatureLen = 64
+
+// signatureFieldLen = tag (0x1a) + độ dài (0x40) + chữ ký.
+const signatureFieldLen = 2 + secp256k1SignatureLen
+
+// Secp256k1PublicKey là public key secp256k1 dạng điểm affine, dùng cho
+// ECDSA emulated của gnark.
+type Secp256k1PublicKey = ecdsa.PublicKey[emulated.Secp256k1Fp, emulated.Secp256k1Fr]
+
+// SignedTxFieldCircuit giống TxHashFieldCircuit nhưng thay vì công khai tx
+// hash, nó chứng minh tx đã được ký: dựng lại SignDoc (body_bytes,
+// auth_info_bytes, chain_id, account_number) từ TxBytes, hash SHA-256 và kiểm
+// tra TxRaw.signatures[0] bằng ECDSA secp256k1 với PublicKey.
+//
+// TxRaw phải có đúng một chữ ký 64 byte, nằm ở cuối tx (SIGN_MODE_DIRECT, một
+// signer). Public input gồm PublicKey, ChainID và các field trong Msgs.
+type SignedTxFieldCircuit struct {
+	PublicKey     Secp256k1PublicKey  `gnark:",public"`
+	ChainID       []frontend.Variable `gnark:",public"`
+	TxBytes       []frontend.Variable `gnark:",secret"`
+	AccountNumber frontend.Variable   `gnark:",secret"`
+	Msgs          []MsgAssertion
+
+	msgConfigs  []MsgConfig
+	txIndexBits int
+}
+
+// NewSignedTxFieldCircuit builds a signed-tx circuit configured for the given
+// Tx length, chain-id length and per-message configs.
+func NewSignedTxFieldCircuit(txLen, chainIDLen int, configs []MsgConfig) *SignedTxFieldCircuit {
+	if txLen <= signatureFieldLen {
+		panic("tx length must be > signature field length")
+	}
+	if chainIDLen == 0 || chainIDLen > 0x7f {
+		panic("chain id length must be in [1, 127]")
+	}
+	inner := NewTxsFieldCircuit(txLen, configs)
+	return &SignedTxFieldCircuit{
+		ChainID:     make([]frontend.Variable, chainIDLen),
+		TxBytes:     inner.PublicTxBytes,
+		Msgs:        inner.Msgs,
+		msgConfigs:  inner.msgConfigs,
+		txIndexBits: inner.txIndexBits,
+	}
+}
+
+func (circuit *SignedTxFieldCircuit) Define(api frontend.API) error {
+	tx := circuit.TxBytes
+	txLen := len(tx)
+	authInfoEnd := txLen - signatureFieldLen
+
+	fields := &TxsFieldCircuit{
+		Msgs:        circuit.Msgs,
+		msgConfigs:  circuit.msgConfigs,
+		txIndexBits: circuit.txIndexBits,
+	}
+	txTable := newTxBytes(api, tx)
+	bodyEnd := fields.verifyTx(api, txTable, txLen)
+
+	// auth_info_bytes kết thúc ngay trước signatures[0], và đó là field cuối.
+	maxIdx := txLen - 1
+	api.AssertIsEqual(selectByteAt(api, txTable, bodyEnd, maxIdx), 0x12)
+	authLenIdx := api.Add(bodyEnd, 1)
+	authLen, authLenBytes := decodeVarint4Bytes(api, txTable, authLenIdx, maxIdx)
+	api.AssertIsEqual(api.Add(authLenIdx, authLenBytes, authLen), authInfoEnd)
+	api.AssertIsEqual(tx[authInfoEnd], 0x1a)
+	api.AssertIsEqual(tx[authInfoEnd+1], secp256k1SignatureLen)
+
+	byteField, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+
+	// SignDoc = body_bytes (1) || auth_info_bytes (2) || chain_id (3) ||
+	// account_number (4). Hai field đầu encode giống hệt phần đầu TxRaw nên
+	// dùng lại tx[:authInfoEnd].
+	signDoc := make([]uints.U8, 0, authInfoEnd+3+len(circuit.ChainID)+MaxVarintLen)
+	for i := 0; i < authInfoEnd; i++ {
+		signDoc = append(signDoc, byteField.ByteValueOf(tx[i]))
+	}
+	signDoc = append(signDoc, uints.NewU8(0x1a), uints.NewU8(uint8(len(circuit.ChainID))))
+	for i := range circuit.ChainID {
+		signDoc = append(signDoc, byteField.ByteValueOf(circuit.ChainID[i]))
+	}
+	minSignDocLen := len(signDoc)
+
+	// account_number = 0 bị bỏ qua khi encode (proto3 default).
+	accountBytes, accountLen := encodeUint64Varint(api, circuit.AccountNumber)
+	signDoc = append(signDoc, uints.NewU8(0x20))
+	for _, b := range accountBytes {
+		signDoc = append(signDoc, byteField.ByteValueOf(b))
+	}
+	hasAccount := api.Sub(1, api.IsZero(circuit.AccountNumber))
+	signDocLen := api.Add(minSignDocLen, api.Mul(hasAccount, api.Add(1, accountLen)))
+
+	hasher, err := sha2.New(api, hash.WithMinimalLength(minSignDocLen))
+	if err != nil {
+		return err
+	}
+	hasher.Write(signDoc)
+	digest := hasher.FixedLengthSum(signDocLen)
+
+	// Tx bytes secret: range-check những byte chưa đi qua ByteValueOf ở trên.
+	for i := authInfoEnd; i < txLen; i++ {
+		byteField.ByteValueOf(tx[i])
+	}
+
+	scalarField, err := emulated.NewField[emulated.Secp256k1Fr](api)
+	if err != nil {
+		return err
+	}
+	digestVars := make([]frontend.Variable, len(digest))
+	for i := range digest {
+		digestVars[i] = digest[i].Val
+	}
+	msgHash := scalarFromBigEndian(api, scalarField, digestVars)
+
+	sigStart := authInfoEnd + 2
+	sig := &ecdsa.Signature[emulated.Secp256k1Fr]{
+		R: *scalarFromBigEndian(api, scalarField, tx[sigStart:sigStart+32]),
+		S: *scalarFromBigEndian(api, scalarField, tx[sigStart+32:sigStart+64]),
+	}
+	circuit.PublicKey.Verify(api, sw_emulated.GetSecp256k1Params(), msgHash, sig)
+	return nil
+}
+
+// encodeUint64Varint encode v (uint64) thành varint protobuf canonical.
+// Returns: (10 byte, byte không dùng = 0; số byte thực sự dùng)
+func encodeUint64Varint(api frontend.API, v frontend.Variable) ([]frontend.Variable, frontend.Variable) {
+	bits := api.ToBinary(v, 64)
+
+	groups := make([]frontend.Variable, MaxVarintLen)
+	for i := range groups {
+		end := min(7*(i+1), len(bits))
+		groups[i] = api.FromBinary(bits[7*i : end]...)
+	}
+
+	// more[i] = 1 khi còn group khác 0 phía sau group i.
+	more := make([]frontend.Variable, MaxVarintLen)
+	more[MaxVarintLen-1] = 0
+	for i := MaxVarintLen - 2; i >= 0; i-- {
+		nonZero := api.Sub(1, api.IsZero(groups[i+1]))
+		more[i] = api.Sub(api.Add(more[i+1], nonZero), api.Mul(more[i+1], nonZero))
+	}
+
+	out := make([]frontend.Variable, MaxVarintLen)
+	length := frontend.Variable(1)
+	for i := range out {
+		out[i] = api.Add(groups[i], api.Mul(more[i], 0x80))
+		if i < MaxVarintLen-1 {
+			length = api.Add(length, more[i])
+		}
+	}
+	return out, length
+}
+
+// scalarFromBigEndian dựng phần tử emulated từ các byte big-endian.
+func scalarFromBigEndian[T emulated.FieldParams](api frontend.API, field *emulated.Field[T], data []frontend.Variable) *emulated.Element[T] {
+	bits := make([]frontend.Variable, 0, 8*len(data))
+	for i := len(data) - 1; i >= 0; i-- {
+		bits = append(bits, api.ToBinary(data[i], 8)...)
+	}
+	return field.FromBits(bits...)
+}
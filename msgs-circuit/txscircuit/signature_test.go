@@ -0,0 +1,90 @@
+package txscircuit
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	secpecdsa "github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/test"
+)
+
+func TestSignedTxFieldCircuit(t *testing.T) {
+	const chainID = "cosmoshub-4"
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	assertions := []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)}
+
+	privKey, err := secpecdsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := secpecdsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, accountNumber := range []uint64{0, 1 << 40} {
+		signTestTx(t, privKey, tx, chainID, accountNumber)
+
+		circuit, assignment := buildSignedTestCircuit(t, tx, chainID, accountNumber, &privKey.PublicKey, assertions)
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("valid signature (account %d) rejected: %v", accountNumber, err)
+		}
+	}
+
+	circuit, assignment := buildSignedTestCircuit(t, tx, chainID, 1<<40, &otherKey.PublicKey, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a signature under a different public key")
+	}
+
+	// Chữ ký cho chain khác không dùng lại được.
+	circuit, assignment = buildSignedTestCircuit(t, tx, "cosmoshub-5", 1<<40, &privKey.PublicKey, assertions)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("circuit accepted a signature for another chain id")
+	}
+}
+
+// signTestTx ký SignDoc của tx và ghi chữ ký vào signatures[0] ở cuối tx.
+func signTestTx(t *testing.T, privKey *secpecdsa.PrivateKey, tx []byte, chainID string, accountNumber uint64) {
+	t.Helper()
+	authInfoEnd := len(tx) - signatureFieldLen
+	signDoc := append([]byte(nil), tx[:authInfoEnd]...)
+	signDoc = appendLengthDelimitedField(signDoc, 3, []byte(chainID))
+	if accountNumber != 0 {
+		signDoc = appendVarintField(signDoc, 4, accountNumber)
+	}
+
+	sig, err := privKey.Sign(signDoc, sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(tx[authInfoEnd+2:], sig)
+}
+
+func buildSignedTestCircuit(
+	t *testing.T,
+	tx []byte,
+	chainID string,
+	accountNumber uint64,
+	pubKey *secpecdsa.PublicKey,
+	assertions []testAssertion,
+) (*SignedTxFieldCircuit, *SignedTxFieldCircuit) {
+	t.Helper()
+	fieldsCircuit, fieldsAssignment := buildTestCircuit(t, tx, assertions)
+
+	circuit := NewSignedTxFieldCircuit(len(tx), len(chainID), fieldsCircuit.msgConfigs)
+	assignment := NewSignedTxFieldCircuit(len(tx), len(chainID), fieldsCircuit.msgConfigs)
+	assignment.TxBytes = fieldsAssignment.PublicTxBytes
+	assignment.Msgs = fieldsAssignment.Msgs
+	assignment.AccountNumber = accountNumber
+	padBytes(assignment.ChainID, []byte(chainID))
+	assignment.PublicKey = Secp256k1PublicKey{
+		X: emulated.ValueOf[emulated.Secp256k1Fp](pubKey.A.X),
+		Y: emulated.ValueOf[emulated.Secp256k1Fp](pubKey.A.Y),
+	}
+	return circuit, assignment
+}
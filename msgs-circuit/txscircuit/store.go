@@ -0,0 +1,259 @@
+package txscircuit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+const (
+	// DefaultStoreDir là thư mục cache artifact mặc định, giống storeDir của
+	// gnark/cmd/groth16.go.
+	DefaultStoreDir = "store"
+
+	circuitFilename      = "txs_circuit.r1cs"
+	provingKeyFilename   = "txs_proving.key"
+	verifyingKeyFilename = "txs_verifying.key"
+
+	// shapeVersion đổi mỗi khi constraint của circuit thay đổi với cùng một
+	// shape, để các artifact cũ trong store không còn được dùng lại.
+	shapeVersion = 8
+)
+
+// circuitShape là mọi tham số compile-time quyết định constraint system của
+// TxsFieldCircuit.
+type circuitShape struct {
+	Version     int
+	TxLen       int
+	VariableLen bool
+	Msgs        []MsgConfig
+	Body        BodyConfig
+	AuthInfo    AuthInfoConfig
+}
+
+// ShapeKey trả về hash của (txLen, configs): hai circuit có cùng ShapeKey có
+// cùng constraint system nên dùng chung được CS/PK/VK.
+func ShapeKey(txLen int, configs []MsgConfig) string {
+	return NewTxsFieldCircuit(txLen, configs).ShapeKey()
+}
+
+// ShapeKey trả về hash của shape circuit, gồm cả cấu hình WithBody,
+// WithAuthInfo và chế độ MaxLen.
+func (circuit *TxsFieldCircuit) ShapeKey() string {
+	shape := circuitShape{
+		Version:     shapeVersion,
+		TxLen:       len(circuit.PublicTxBytes),
+		VariableLen: circuit.variableLen,
+		Msgs:        circuit.msgConfigs,
+		Body:        circuit.bodyConfig,
+		AuthInfo:    circuit.authInfoConfig,
+	}
+	data, err := json.Marshal(shape)
+	if err != nil {
+		panic(fmt.Errorf("marshal circuit shape: %w", err))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Artifacts là kết quả compile + Groth16 setup của một shape circuit.
+type Artifacts struct {
+	CS constraint.ConstraintSystem
+	PK groth16.ProvingKey
+	VK groth16.VerifyingKey
+}
+
+// ArtifactStore cache Artifacts trên đĩa, mỗi ShapeKey một thư mục con trong
+// Dir: compile và setup một lần, prove nhiều lần cho các tx cùng shape.
+//
+// Curve là curve compile circuit. Artifact BN254 nằm ngay trong Dir, curve
+// khác (vd. BLS12-377 cho AggregateCircuit) nằm trong Dir/<curve>.
+type ArtifactStore struct {
+	Dir   string
+	Curve ecc.ID
+}
+
+// NewArtifactStore trả về store BN254 đặt tại dir.
+func NewArtifactStore(dir string) *ArtifactStore {
+	return NewArtifactStoreForCurve(dir, ecc.BN254)
+}
+
+// NewArtifactStoreForCurve trả về store đặt tại dir cho circuit compile trên
+// curve.
+func NewArtifactStoreForCurve(dir string, curve ecc.ID) *ArtifactStore {
+	return &ArtifactStore{Dir: dir, Curve: curve}
+}
+
+// Path là thư mục chứa artifact của key.
+func (s *ArtifactStore) Path(key string) string {
+	return filepath.Join(s.curveDir(), key)
+}
+
+func (s *ArtifactStore) curveDir() string {
+	if s.Curve == ecc.BN254 {
+		return s.Dir
+	}
+	return filepath.Join(s.Dir, s.Curve.String())
+}
+
+// ShapedCircuit là circuit có ShapeKey (TxsFieldCircuit, AggregateCircuit).
+type ShapedCircuit interface {
+	frontend.Circuit
+	ShapeKey() string
+}
+
+// LoadOrSetup đọc artifact của shape circuit từ store; nếu chưa có thì compile,
+// chạy groth16.Setup và lưu lại. cached = true khi artifact lấy từ store.
+func (s *ArtifactStore) LoadOrSetup(circuit ShapedCircuit) (artifacts *Artifacts, cached bool, err error) {
+	key := circuit.ShapeKey()
+	artifacts, err = s.Load(key)
+	if err == nil {
+		return artifacts, true, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	cs, err := frontend.Compile(s.Curve.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		return nil, false, fmt.Errorf("compile circuit: %w", err)
+	}
+	pk, vk, err := groth16.Setup(cs)
+	if err != nil {
+		return nil, false, fmt.Errorf("setup: %w", err)
+	}
+	artifacts = &Artifacts{CS: cs, PK: pk, VK: vk}
+	if err := s.Save(key, artifacts); err != nil {
+		return nil, false, err
+	}
+	return artifacts, false, nil
+}
+
+// Load đọc artifact của key; lỗi bọc os.ErrNotExist khi key chưa có trong
+// store.
+func (s *ArtifactStore) Load(key string) (*Artifacts, error) {
+	dir := s.Path(key)
+	cs, err := readConstraintSystem(s.Curve, filepath.Join(dir, circuitFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read circuit: %w", err)
+	}
+	pk, err := readProvingKey(s.Curve, filepath.Join(dir, provingKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read proving key: %w", err)
+	}
+	vk, err := readVerifyingKey(s.Curve, filepath.Join(dir, verifyingKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read verifying key: %w", err)
+	}
+	return &Artifacts{CS: cs, PK: pk, VK: vk}, nil
+}
+
+// LoadVerifyingKey chỉ đọc verifying key của key, đủ cho phía verifier.
+func (s *ArtifactStore) LoadVerifyingKey(key string) (groth16.VerifyingKey, error) {
+	vk, err := readVerifyingKey(s.Curve, filepath.Join(s.Path(key), verifyingKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read verifying key: %w", err)
+	}
+	return vk, nil
+}
+
+// Save ghi artifact của key. File được ghi vào thư mục tạm rồi rename, nên
+// một lần ghi dở dang không để lại entry hỏng trong store.
+func (s *ArtifactStore) Save(key string, artifacts *Artifacts) error {
+	return saveEntry(s.Path(key), artifacts.CS, artifacts.PK, artifacts.VK)
+}
+
+// saveEntry ghi CS/PK/VK vào thư mục tạm cạnh dir rồi rename thành dir.
+func saveEntry(dir string, cs constraint.ConstraintSystem, pk, vk io.WriterTo) error {
+	parent, key := filepath.Split(dir)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return fmt.Errorf("store dir: %w", err)
+	}
+	tmp, err := os.MkdirTemp(parent, key+".tmp-")
+	if err != nil {
+		return fmt.Errorf("store dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := WriteToFile(filepath.Join(tmp, circuitFilename), cs); err != nil {
+		return fmt.Errorf("write circuit: %w", err)
+	}
+	if err := WriteToFile(filepath.Join(tmp, provingKeyFilename), pk); err != nil {
+		return fmt.Errorf("write proving key: %w", err)
+	}
+	if err := WriteToFile(filepath.Join(tmp, verifyingKeyFilename), vk); err != nil {
+		return fmt.Errorf("write verifying key: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("commit store entry: %w", err)
+	}
+	return nil
+}
+
+// WriteToFile ghi wt (proof, key, witness, bundle...) vào path, ghi đè file
+// cũ nếu có. Lỗi khi đóng file cũng được trả về vì ghi có thể chỉ thất bại lúc
+// flush.
+func WriteToFile(path string, wt io.WriterTo) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := wt.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readVerifyingKey(curve ecc.ID, path string) (groth16.VerifyingKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vk := groth16.NewVerifyingKey(curve)
+	if _, err := vk.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+func readProvingKey(curve ecc.ID, path string) (groth16.ProvingKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pk := groth16.NewProvingKey(curve)
+	if _, err := pk.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+func readConstraintSystem(curve ecc.ID, path string) (constraint.ConstraintSystem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cs := groth16.NewCS(curve)
+	if _, err := cs.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
@@ -0,0 +1,150 @@
+package txscircuit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+)
+
+func TestShapeKey(t *testing.T) {
+	configs := []MsgConfig{{FieldValueLen: 14, MsgValueLen: 110, MaxTypeURLLen: 28}}
+
+	key := ShapeKey(300, configs)
+	if key != ShapeKey(300, []MsgConfig{configs[0]}) {
+		t.Fatal("same shape produced different keys")
+	}
+
+	other := []MsgConfig{{FieldValueLen: 15, MsgValueLen: 110, MaxTypeURLLen: 28}}
+	variants := map[string]string{
+		"txLen":   ShapeKey(301, configs),
+		"configs": ShapeKey(300, other),
+		"maxLen":  NewTxsFieldCircuitMaxLen(300, configs).ShapeKey(),
+		"body":    NewTxsFieldCircuit(300, configs).WithBody(BodyConfig{MaxMemoLen: 8}).ShapeKey(),
+	}
+	for name, variant := range variants {
+		if variant == key {
+			t.Errorf("%s change did not change the shape key", name)
+		}
+	}
+}
+
+// TestArtifactStoreReuse kiểm tra lần setup thứ hai lấy artifact từ store và
+// proving key đọc lại vẫn tạo proof hợp lệ cho một tx khác cùng shape.
+func TestArtifactStoreReuse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("groth16 setup is slow")
+	}
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	circuit, _ := buildTestCircuit(t, tx, []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)})
+
+	store := NewArtifactStore(t.TempDir())
+	if _, cached, err := store.LoadOrSetup(circuit); err != nil || cached {
+		t.Fatalf("first LoadOrSetup: cached=%v err=%v", cached, err)
+	}
+	artifacts, cached, err := store.LoadOrSetup(circuit)
+	if err != nil || !cached {
+		t.Fatalf("second LoadOrSetup: cached=%v err=%v", cached, err)
+	}
+
+	// Cùng độ dài amount nên cùng shape.
+	otherValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "9999"))
+	otherTx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", otherValue))
+	otherCircuit, assignment := buildTestCircuit(t, otherTx, []testAssertion{lastFieldAssertion(t, otherValue, 0x1a)})
+	if otherCircuit.ShapeKey() != circuit.ShapeKey() {
+		t.Fatal("txs with the same shape produced different keys")
+	}
+
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(artifacts.CS, artifacts.PK, fullWitness)
+	if err != nil {
+		t.Fatalf("prove with cached key: %v", err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, artifacts.VK, publicWitness); err != nil {
+		t.Fatalf("verify with cached key: %v", err)
+	}
+}
+
+// TestArtifactStorePlonk: artifact PLONK nằm cạnh artifact Groth16 của cùng
+// shape và proving key đọc lại từ store vẫn prove được.
+func TestArtifactStorePlonk(t *testing.T) {
+	if testing.Short() {
+		t.Skip("plonk setup is slow")
+	}
+
+	sendValue := msgSendValue(testFromAddr, testToAddr, coinBytes("uatom", "4242"))
+	tx := buildTestTx(anyBytes("/cosmos.bank.v1beta1.MsgSend", sendValue))
+	circuit, assignment := buildTestCircuit(t, tx, []testAssertion{lastFieldAssertion(t, sendValue, 0x1a)})
+
+	store := NewArtifactStore(t.TempDir())
+	if _, cached, err := store.LoadOrSetupPlonk(circuit); err != nil || cached {
+		t.Fatalf("first LoadOrSetupPlonk: cached=%v err=%v", cached, err)
+	}
+	if _, err := store.Load(circuit.ShapeKey()); err == nil {
+		t.Fatal("plonk setup created a groth16 store entry")
+	}
+	artifacts, cached, err := store.LoadOrSetupPlonk(circuit)
+	if err != nil || !cached {
+		t.Fatalf("second LoadOrSetupPlonk: cached=%v err=%v", cached, err)
+	}
+
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := plonk.Prove(artifacts.CS, artifacts.PK, fullWitness)
+	if err != nil {
+		t.Fatalf("prove with cached key: %v", err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk, err := store.LoadPlonkVerifyingKey(circuit.ShapeKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plonk.Verify(proof, vk, publicWitness); err != nil {
+		t.Fatalf("verify with cached key: %v", err)
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, name := range []string{"groth16", "plonk"} {
+		if backend, err := ParseBackend(name); err != nil || string(backend) != name {
+			t.Errorf("ParseBackend(%q) = %q, %v", name, backend, err)
+		}
+	}
+	if _, err := ParseBackend("marlin"); err == nil {
+		t.Error("accepted an unknown backend")
+	}
+}
+
+// Store BN254 giữ layout cũ; curve khác nằm trong thư mục con riêng để cùng
+// shape key không đè artifact của nhau.
+func TestArtifactStoreCurveDirs(t *testing.T) {
+	const key = "abc"
+	bn254 := NewArtifactStore("store")
+	bls := NewArtifactStoreForCurve("store", InnerCurve)
+	if got, want := bn254.Path(key), filepath.Join("store", key); got != want {
+		t.Errorf("bn254 path = %s, want %s", got, want)
+	}
+	if got, want := bls.Path(key), filepath.Join("store", "bls12_377", key); got != want {
+		t.Errorf("bls12-377 path = %s, want %s", got, want)
+	}
+	if got, want := bls.PlonkPath(key), filepath.Join("store", "bls12_377", "plonk", key); got != want {
+		t.Errorf("bls12-377 plonk path = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package txscircuit
+
+import "github.com/consensys/gnark/frontend"
+
+// SubArray trả về cửa sổ arr[start : start+length] pad 0 tới maxLen phần tử:
+// out[j] = arr[start+j] với j < length, out[j] = 0 với j >= length. Circuit
+// ràng buộc length <= maxLen và start + length <= len(arr).
+//
+// Cửa sổ được đọc qua bảng lookup log-derivative dựng trên arr, nên tổng chi
+// phí là O(len(arr) + maxLen) constraint.
+//
+// Không dùng so khớp bằng random linear combination (api.Commit): mỗi byte ở
+// đây đã chỉ tốn ~7 constraint, còn RLC cần đọc tổng tiền tố phụ thuộc
+// challenge tại vị trí biến. Bảng lookup cho các giá trị đó phải được dựng sau
+// commitment, trong khi multicommit chỉ cho một commitment và các bảng
+// lookup hiện có đã dùng nó; thay bằng mux thì tốn O(n) mỗi cửa sổ, đắt hơn
+// cách hiện tại (xem case3-payload trong BenchmarkTxsFieldCircuitConstraints).
+func SubArray(api frontend.API, arr []frontend.Variable, start, length frontend.Variable, maxLen int) []frontend.Variable {
+	tx := newTxBytes(api, arr)
+	return subArray(api, tx, start, length, maxLen, len(arr)-1)
+}
+
+// subArray giống SubArray nhưng dùng bảng lookup sẵn có của tx; cửa sổ phải
+// nằm trong [0, maxIdx]. Chỉ kiểm tra biên một lần cho cả cửa sổ, mỗi byte
+// sau đó chỉ tốn một lookup.
+func subArray(api frontend.API, tx *txBytes, start, length frontend.Variable, maxLen int, maxIdx frontend.Variable) []frontend.Variable {
+	// length <= maxLen
+	api.ToBinary(api.Sub(maxLen, length), bitsFor(maxLen+1))
+	// 0 <= length: length âm (p - k) vẫn làm maxLen - length nhỏ nên phải
+	// range-check riêng.
+	api.ToBinary(length, bitsFor(maxLen+1))
+	// start + length <= maxIdx + 1
+	assertLessOrEqual(api, api.Add(start, length), api.Add(maxIdx, 1), bitsFor(len(tx.vars))+1)
+
+	out := make([]frontend.Variable, maxLen)
+	isPadding := frontend.Variable(0)
+	for j := range out {
+		isPadding = api.Add(isPadding, api.IsZero(api.Sub(j, length)))
+		inRange := api.Sub(1, isPadding)
+		// Ngoài phạm vi thì đọc tx[0] để index luôn hợp lệ; kết quả bị nhân 0.
+		idx := api.Mul(inRange, api.Add(start, j))
+		out[j] = api.Mul(inRange, tx.table.Lookup(idx)[0])
+	}
+	return out
+}
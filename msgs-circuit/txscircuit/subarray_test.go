@@ -0,0 +1,74 @@
+package txscircuit
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// subArrayCircuit ràng buộc Window = SubArray(Data, Start, Len).
+type subArrayCircuit struct {
+	Data   []frontend.Variable
+	Start  frontend.Variable
+	Len    frontend.Variable
+	Window []frontend.Variable `gnark:",public"`
+}
+
+func (c *subArrayCircuit) Define(api frontend.API) error {
+	window := SubArray(api, c.Data, c.Start, c.Len, len(c.Window))
+	for j := range window {
+		api.AssertIsEqual(window[j], c.Window[j])
+	}
+	return nil
+}
+
+func TestSubArray(t *testing.T) {
+	data := []byte("0123456789abcdef0123")
+	const maxLen = 6
+
+	tests := []struct {
+		name   string
+		start  int
+		length int
+		window string
+		valid  bool
+	}{
+		{name: "prefix", start: 0, length: 6, window: "012345", valid: true},
+		{name: "middle", start: 9, length: 4, window: "9abc", valid: true},
+		{name: "suffix", start: 17, length: 3, window: "123", valid: true},
+		{name: "empty", start: 20, length: 0, window: "", valid: true},
+		{name: "wrong byte", start: 9, length: 4, window: "9abd"},
+		{name: "wrong length", start: 9, length: 3, window: "9abc"},
+		{name: "past end", start: 18, length: 3, window: "23"},
+		{name: "longer than maxLen", start: 0, length: 7, window: "012345"},
+		// -1 mod p: maxLen - length vẫn nhỏ nhưng cửa sổ đọc đủ maxLen byte.
+		{name: "negative length", start: 9, length: -1, window: "9abcde"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			circuit := &subArrayCircuit{
+				Data:   make([]frontend.Variable, len(data)),
+				Window: make([]frontend.Variable, maxLen),
+			}
+			assignment := &subArrayCircuit{
+				Data:   make([]frontend.Variable, len(data)),
+				Start:  tt.start,
+				Len:    tt.length,
+				Window: make([]frontend.Variable, maxLen),
+			}
+			padBytes(assignment.Data, data)
+			padBytes(assignment.Window, []byte(tt.window))
+
+			err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+			if tt.valid && err != nil {
+				t.Fatalf("valid window rejected: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("invalid window accepted")
+			}
+		})
+	}
+}
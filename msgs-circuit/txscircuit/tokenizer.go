@@ -0,0 +1,111 @@
+package txscircuit
+
+import "github.com/consensys/gnark/frontend"
+
+// DefaultMaxMsgFields là số field tối đa được duyệt trong value của một
+// message khi MsgConfig.MaxFields = 0.
+const DefaultMaxMsgFields = 8
+
+// MaxTxRawFields là số field tối đa của TxRaw mà circuit duyệt qua:
+// body_bytes, auth_info_bytes và tới 6 signatures.
+const MaxTxRawFields = 8
+
+// extensionKeyByte là byte đầu của key 2 byte cho field number 1023/2047
+// (wire type 2).
+const extensionKeyByte = 0xfa
+
+// fieldToken là một field (key, varint, payload) mà tokenizeFields đọc được.
+type fieldToken struct {
+	Active   frontend.Variable // 1 nếu bước này ứng với một field thật
+	Start    frontend.Variable // vị trí byte key trong tx
+	Key      frontend.Variable // (field number << 3) | wire type
+	WireType frontend.Variable
+	End      frontend.Variable // vị trí ngay sau payload
+}
+
+// tokenizeFields bước tuần tự qua các field protobuf nằm trong [start, end)
+// và chứng minh chúng phủ kín khoảng này trong tối đa maxFields bước. Mỗi
+// token trả về bắt đầu đúng tại biên field, nên caller chỉ cần so sánh vị trí
+// với Start để biết một offset có phải biên thật hay không.
+//
+// Hỗ trợ key 1 byte (field number < 16) với wire type 0 (varint uint64, tối
+// đa 10 byte) hoặc 2 (length-delimited).
+func tokenizeFields(
+	api frontend.API,
+	tx *txBytes,
+	start frontend.Variable,
+	end frontend.Variable,
+	maxFields int,
+	maxIdx frontend.Variable,
+) []fieldToken {
+	return tokenizeFieldsExt(api, tx, start, end, maxFields, maxIdx, false)
+}
+
+// tokenizeFieldsExt giống tokenizeFields; allowExtensionKeys chấp nhận thêm
+// key 2 byte của TxBody.extension_options (0xfa 0x3f) và
+// non_critical_extension_options (0xfa 0x7f). Token của chúng có Key = 0xfa.
+func tokenizeFieldsExt(
+	api frontend.API,
+	tx *txBytes,
+	start frontend.Variable,
+	end frontend.Variable,
+	maxFields int,
+	maxIdx frontend.Variable,
+	allowExtensionKeys bool,
+) []fieldToken {
+	tokens := make([]fieldToken, maxFields)
+
+	cursor := start
+	done := api.IsZero(api.Sub(cursor, end))
+	for k := 0; k < maxFields; k++ {
+		active := api.Sub(1, done)
+
+		// Khi đã duyệt hết thì đọc lại tag body của TxRaw tại index 0 (Define
+		// đã chứng minh đó là key + varint hợp lệ), nhờ vậy các ràng buộc
+		// bên dưới không cần gating theo active.
+		pos := api.Mul(active, cursor)
+
+		keyByte := selectByteAt(api, tx, pos, maxIdx)
+		keyBits := api.ToBinary(keyByte, 8)
+		isExtension := frontend.Variable(0)
+		if allowExtensionKeys {
+			// 0xfa có wire type 2 ở 3 bit thấp nên các kiểm tra bên dưới vẫn
+			// đúng; chỉ byte thứ hai của key cần kiểm tra riêng.
+			isExtension = api.IsZero(api.Sub(keyByte, extensionKeyByte))
+			keyByte2 := selectByteAt(api, tx, api.Add(pos, 1), maxIdx)
+			api.AssertIsEqual(api.Mul(isExtension, api.Sub(keyByte2, 0x3f), api.Sub(keyByte2, 0x7f)), 0)
+		}
+		api.AssertIsEqual(api.Mul(api.Sub(1, isExtension), keyBits[7]), 0)
+
+		wireType := api.FromBinary(keyBits[0], keyBits[1], keyBits[2])
+		fieldNumber := api.FromBinary(keyBits[3:7]...)
+		api.AssertIsEqual(api.IsZero(fieldNumber), 0)
+
+		// wire type ∈ {0, 2} ⇔ bit0 = 0 và bit2 = 0
+		api.AssertIsEqual(keyBits[0], 0)
+		api.AssertIsEqual(keyBits[2], 0)
+		isLengthDelimited := keyBits[1]
+
+		// Với wire type 0, varint chính là giá trị; với wire type 2 là độ dài.
+		varintIdx := api.Add(pos, 1, isExtension)
+		varintValue, varintBytes := decodeUint64Varint(api, tx, varintIdx, maxIdx)
+		next := api.Add(varintIdx, varintBytes, api.Mul(isLengthDelimited, varintValue))
+		api.AssertIsLessOrEqual(api.Select(active, next, end), end)
+
+		tokens[k] = fieldToken{
+			Active:   active,
+			Start:    pos,
+			Key:      keyByte,
+			WireType: wireType,
+			End:      next,
+		}
+
+		cursor = api.Select(active, next, cursor)
+		done = api.IsZero(api.Sub(cursor, end))
+	}
+
+	// Còn field chưa đọc sau maxFields bước => từ chối thay vì bỏ qua phần đuôi.
+	api.AssertIsEqual(done, 1)
+
+	return tokens
+}
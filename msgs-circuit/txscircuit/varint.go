@@ -0,0 +1,124 @@
+package txscircuit
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// MaxVarintLen là số byte tối đa của một varint protobuf (uint64).
+const MaxVarintLen = 10
+
+var (
+	ErrVarintTruncated    = errors.New("txscircuit: truncated varint")
+	ErrVarintTooLong      = errors.New("txscircuit: varint longer than allowed")
+	ErrVarintNonCanonical = errors.New("txscircuit: non-canonical varint")
+	ErrVarintOverflow     = errors.New("txscircuit: varint overflows uint64")
+)
+
+// DecodeVarintN là bản off-circuit của decodeVarintN: decode varint tối đa
+// maxBytes byte ở đầu data và từ chối đúng những encoding mà circuit từ chối.
+// Returns: (decoded value, number of bytes used, error)
+func DecodeVarintN(data []byte, maxBytes int) (uint64, int, error) {
+	checkVarintMaxBytes(maxBytes)
+
+	var value uint64
+	for i := 0; i < maxBytes; i++ {
+		if i >= len(data) {
+			return 0, 0, ErrVarintTruncated
+		}
+		b := data[i]
+		if i == MaxVarintLen-1 && b&0x7f > 1 {
+			return 0, 0, ErrVarintOverflow
+		}
+		value |= uint64(b&0x7f) << (7 * i)
+		if b&0x80 != 0 {
+			continue
+		}
+		if i > 0 && b == 0 {
+			return 0, 0, ErrVarintNonCanonical
+		}
+		return value, i + 1, nil
+	}
+	return 0, 0, ErrVarintTooLong
+}
+
+func checkVarintMaxBytes(maxBytes int) {
+	if maxBytes < 1 || maxBytes > MaxVarintLen {
+		panic("txscircuit: varint maxBytes must be in [1, 10]")
+	}
+}
+
+func decodeVarintByte(api frontend.API, b frontend.Variable) (frontend.Variable, frontend.Variable) {
+	bits := api.ToBinary(b, 8)
+	value := frontend.Variable(0)
+	for i := 0; i < 7; i++ {
+		value = api.Add(value, api.Mul(bits[i], frontend.Variable(1<<i)))
+	}
+	return value, bits[7]
+}
+
+// decodeVarintN decodes a protobuf varint of up to maxBytes bytes at tx[idx].
+// Returns: (decoded value, number of bytes used)
+// Bắt buộc dạng canonical (shortest form): varint dài hơn 1 byte thì byte cuối
+// phải khác 0; byte thứ maxBytes phải có msb = 0; với maxBytes = 10 byte cuối
+// chỉ còn 1 bit (9*7 = 63 bit đã dùng) để giá trị vừa uint64.
+// Các byte đọc thử được lấy thẳng từ bảng lookup, vốn có thêm MaxVarintLen-1
+// byte 0 sau cuối tx, nên varint nằm sát cuối tx vẫn decode được; caller tự
+// kiểm tra các byte thực sự thuộc varint nằm trong giới hạn của mình.
+func decodeVarintN(api frontend.API, tx *txBytes, idx frontend.Variable, maxBytes int) (frontend.Variable, frontend.Variable) {
+	checkVarintMaxBytes(maxBytes)
+
+	value := frontend.Variable(0)
+	bytesUsed := frontend.Variable(1)
+	// inVarint = 1 khi byte thứ i thuộc varint (mọi byte trước đó có msb = 1).
+	inVarint := frontend.Variable(1)
+	for i := 0; i < maxBytes; i++ {
+		b := tx.table.Lookup(api.Add(idx, i))[0]
+		val, msb := decodeVarintByte(api, b)
+
+		value = api.Add(value, api.Mul(inVarint, val, new(big.Int).Lsh(big.NewInt(1), uint(7*i))))
+
+		if i > 0 {
+			// Byte cuối của varint nhiều byte không được bằng 0.
+			isLast := api.Mul(inVarint, api.Sub(1, msb))
+			api.AssertIsEqual(api.Mul(isLast, api.IsZero(val)), 0)
+		}
+		if i == maxBytes-1 {
+			api.AssertIsEqual(api.Mul(inVarint, msb), 0)
+			if i == MaxVarintLen-1 {
+				api.AssertIsEqual(api.Mul(inVarint, val, api.Sub(val, 1)), 0)
+			}
+			break
+		}
+
+		inVarint = api.Mul(inVarint, msb)
+		bytesUsed = api.Add(bytesUsed, inVarint)
+	}
+
+	return value, bytesUsed
+}
+
+// decodeVarint4Bytes decodes a varint with up to 4 bytes support
+// Returns: (decoded value, number of bytes used)
+// Max value: 2^28 - 1 = 268,435,455 (~256MB)
+// Các byte thực sự thuộc varint phải có index <= maxIdx.
+func decodeVarint4Bytes(api frontend.API, tx *txBytes, startIdx frontend.Variable, maxIdx frontend.Variable) (frontend.Variable, frontend.Variable) {
+	return decodeVarintBounded(api, tx, startIdx, maxIdx, 4)
+}
+
+// decodeUint64Varint decodes a protobuf varint of up to 10 bytes (uint64).
+// Returns: (decoded value, number of bytes used)
+func decodeUint64Varint(api frontend.API, tx *txBytes, startIdx frontend.Variable, maxIdx frontend.Variable) (frontend.Variable, frontend.Variable) {
+	return decodeVarintBounded(api, tx, startIdx, maxIdx, MaxVarintLen)
+}
+
+func decodeVarintBounded(api frontend.API, tx *txBytes, startIdx frontend.Variable, maxIdx frontend.Variable, maxBytes int) (frontend.Variable, frontend.Variable) {
+	value, bytesUsed := decodeVarintN(api, tx, startIdx, maxBytes)
+
+	lastIdx := api.Add(startIdx, api.Sub(bytesUsed, 1))
+	assertLessOrEqual(api, lastIdx, maxIdx, bitsFor(len(tx.vars))+1)
+
+	return value, bytesUsed
+}
@@ -0,0 +1,110 @@
+package txscircuit
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// varintCircuit decode varint ở đầu Data bằng decodeVarintN.
+type varintCircuit struct {
+	Data  []frontend.Variable
+	Value frontend.Variable `gnark:",public"`
+	Len   frontend.Variable `gnark:",public"`
+
+	maxBytes int
+}
+
+func (c *varintCircuit) Define(api frontend.API) error {
+	value, bytesUsed := decodeVarintN(api, newTxBytes(api, c.Data), 0, c.maxBytes)
+	api.AssertIsEqual(value, c.Value)
+	api.AssertIsEqual(bytesUsed, c.Len)
+	return nil
+}
+
+func solveVarint(encoded []byte, maxBytes int, value uint64, n int) error {
+	// Pad để các byte đọc thử luôn nằm trong mảng.
+	data := make([]frontend.Variable, MaxVarintLen+1)
+	for i := range data {
+		data[i] = 0
+		if i < len(encoded) {
+			data[i] = encoded[i]
+		}
+	}
+	circuit := &varintCircuit{Data: make([]frontend.Variable, len(data)), maxBytes: maxBytes}
+	assignment := &varintCircuit{Data: data, Value: value, Len: n}
+	return test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+}
+
+func TestDecodeVarintN(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoded  []byte
+		maxBytes int
+		value    uint64
+		n        int
+		err      error
+	}{
+		{name: "0", encoded: []byte{0x00}, maxBytes: 1, value: 0, n: 1},
+		{name: "127", encoded: []byte{0x7f}, maxBytes: 1, value: 127, n: 1},
+		{name: "128", encoded: []byte{0x80, 0x01}, maxBytes: 2, value: 128, n: 2},
+		{name: "128 in 1 byte", encoded: []byte{0x80, 0x01}, maxBytes: 1, err: ErrVarintTooLong},
+		{name: "16383", encoded: []byte{0xff, 0x7f}, maxBytes: 4, value: 16383, n: 2},
+		{name: "16384", encoded: []byte{0x80, 0x80, 0x01}, maxBytes: 4, value: 16384, n: 3},
+		{name: "2^28-1", encoded: []byte{0xff, 0xff, 0xff, 0x7f}, maxBytes: 4, value: 1<<28 - 1, n: 4},
+		{name: "2^28 in 4 bytes", encoded: []byte{0x80, 0x80, 0x80, 0x80, 0x01}, maxBytes: 4, err: ErrVarintTooLong},
+		{name: "2^28", encoded: []byte{0x80, 0x80, 0x80, 0x80, 0x01}, maxBytes: 10, value: 1 << 28, n: 5},
+		{
+			name:     "2^63",
+			encoded:  []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01},
+			maxBytes: 10, value: 1 << 63, n: 10,
+		},
+		{
+			name:     "2^64-1",
+			encoded:  []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+			maxBytes: 10, value: math.MaxUint64, n: 10,
+		},
+		{
+			name:     "2^64",
+			encoded:  []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x02},
+			maxBytes: 10, err: ErrVarintOverflow,
+		},
+		{
+			name:     "11 bytes",
+			encoded:  []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00},
+			maxBytes: 10, err: ErrVarintTooLong,
+		},
+		{name: "non-canonical 0", encoded: []byte{0x80, 0x00}, maxBytes: 10, err: ErrVarintNonCanonical},
+		{name: "non-canonical 1", encoded: []byte{0x81, 0x80, 0x00}, maxBytes: 10, err: ErrVarintNonCanonical},
+		{name: "truncated", encoded: []byte{0x80}, maxBytes: 10, err: ErrVarintTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, n, err := DecodeVarintN(tt.encoded, tt.maxBytes)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("DecodeVarintN error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				// Circuit phải từ chối encoding với mọi giá trị/độ dài.
+				if solveVarint(tt.encoded, tt.maxBytes, 0, 1) == nil {
+					t.Fatal("circuit accepted an invalid varint")
+				}
+				return
+			}
+			if value != tt.value || n != tt.n {
+				t.Fatalf("DecodeVarintN = (%d, %d), want (%d, %d)", value, n, tt.value, tt.n)
+			}
+			if err := solveVarint(tt.encoded, tt.maxBytes, tt.value, tt.n); err != nil {
+				t.Fatalf("circuit rejected valid varint: %v", err)
+			}
+			if solveVarint(tt.encoded, tt.maxBytes, tt.value+1, tt.n) == nil {
+				t.Fatal("circuit accepted a wrong decoded value")
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package witness
+
+import (
+	"fmt"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+)
+
+// Wire type protobuf.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// maxFieldNumber là field number lớn nhất protobuf cho phép (2^29 - 1).
+const maxFieldNumber = 1<<29 - 1
+
+// field là một field protobuf đã parse; mọi vị trí là index tuyệt đối trong tx.
+type field struct {
+	Number   int
+	WireType int
+	Start    int // byte đầu tiên của key
+	KeyLen   int
+	// Varint là giá trị với wire type 0 và độ dài payload với wire type 2.
+	Varint       uint64
+	PayloadStart int // đầu payload (wire type 2)
+	End          int // ngay sau field
+}
+
+func (f field) payload(tx []byte) []byte {
+	return tx[f.PayloadStart:f.End]
+}
+
+// parseFields parse toàn bộ các field trong tx[start:end]. Key và varint phải
+// ở dạng canonical như circuit yêu cầu; group (wire type 3/4) bị từ chối.
+func parseFields(tx []byte, start, end int) ([]field, error) {
+	var fields []field
+	for pos := start; pos < end; {
+		key, keyLen, err := txscircuit.DecodeVarintN(tx[pos:end], txscircuit.MaxVarintLen)
+		if err != nil {
+			return nil, &ParseError{Offset: pos, Err: err}
+		}
+		number := key >> 3
+		if number == 0 || number > maxFieldNumber {
+			return nil, &ParseError{Offset: pos, Err: fmt.Errorf("%w: field number %d", ErrMalformedTx, number)}
+		}
+
+		f := field{Number: int(number), WireType: int(key & 7), Start: pos, KeyLen: keyLen}
+		dataStart := pos + keyLen
+		switch f.WireType {
+		case wireVarint:
+			value, n, err := txscircuit.DecodeVarintN(tx[dataStart:end], txscircuit.MaxVarintLen)
+			if err != nil {
+				return nil, &ParseError{Offset: dataStart, Err: err}
+			}
+			f.Varint = value
+			f.End = dataStart + n
+		case wireBytes:
+			length, n, err := txscircuit.DecodeVarintN(tx[dataStart:end], txscircuit.MaxVarintLen)
+			if err != nil {
+				return nil, &ParseError{Offset: dataStart, Err: err}
+			}
+			if length > uint64(end-dataStart-n) {
+				return nil, &ParseError{Offset: pos, Err: fmt.Errorf("%w: field %d overruns its parent", ErrMalformedTx, number)}
+			}
+			f.Varint = length
+			f.PayloadStart = dataStart + n
+			f.End = f.PayloadStart + int(length)
+		case wireFixed64, wireFixed32:
+			size := 8
+			if f.WireType == wireFixed32 {
+				size = 4
+			}
+			if size > end-dataStart {
+				return nil, &ParseError{Offset: pos, Err: fmt.Errorf("%w: field %d overruns its parent", ErrMalformedTx, number)}
+			}
+			f.End = dataStart + size
+		default:
+			return nil, &ParseError{Offset: pos, Err: fmt.Errorf("%w: wire type %d", ErrMalformedTx, f.WireType)}
+		}
+
+		fields = append(fields, f)
+		pos = f.End
+	}
+	return fields, nil
+}
+
+// checkTokenizable kiểm tra tokenizeFields của circuit đọc được các field: key
+// 1 byte (field number < 16) và wire type 0 hoặc 2.
+func checkTokenizable(fields []field) error {
+	for _, f := range fields {
+		if f.KeyLen != 1 {
+			return &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: field number %d needs a multi-byte key", ErrUnsupportedTx, f.Number)}
+		}
+		if f.WireType != wireVarint && f.WireType != wireBytes {
+			return &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: field %d has wire type %d", ErrUnsupportedTx, f.Number, f.WireType)}
+		}
+	}
+	return nil
+}
+
+// nthOccurrence trả về phần tử thứ index (đếm từ 0) của field repeated number.
+func nthOccurrence(fields []field, number, index int) (field, error) {
+	found, first := -1, -1
+	count := 0
+	for i, f := range fields {
+		if f.Number != number {
+			continue
+		}
+		if first < 0 {
+			first = i
+		} else if fields[first].WireType != f.WireType {
+			return field{}, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: field %d mixes wire types", ErrWireType, number)}
+		}
+		if count == index {
+			found = i
+		}
+		count++
+	}
+	if found < 0 {
+		return field{}, fmt.Errorf("%w: field %d has %d elements, no index %d", ErrFieldNotFound, number, count, index)
+	}
+	return fields[found], nil
+}
+
+// countOccurrences đếm số field có field number number.
+func countOccurrences(fields []field, number int) int {
+	n := 0
+	for _, f := range fields {
+		if f.Number == number {
+			n++
+		}
+	}
+	return n
+}
+
+// lastOccurrence trả về occurrence cuối cùng của field number, tức giá trị mà
+// decoder protobuf sử dụng.
+func lastOccurrence(fields []field, number int) (field, error) {
+	found := -1
+	for i, f := range fields {
+		if f.Number != number {
+			continue
+		}
+		if found >= 0 && fields[found].WireType != f.WireType {
+			return field{}, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: field %d mixes wire types", ErrWireType, number)}
+		}
+		found = i
+	}
+	if found < 0 {
+		return field{}, fmt.Errorf("%w: field %d", ErrFieldNotFound, number)
+	}
+	return fields[found], nil
+}
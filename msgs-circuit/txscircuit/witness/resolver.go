@@ -0,0 +1,169 @@
+package witness
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/gogoproto/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// anyWireType đánh dấu FieldRef không ràng buộc wire type (spec dùng
+// FieldNumbers trực tiếp).
+const anyWireType = -1
+
+// FieldRef là một bước trên đường dẫn field đã resolve. Với field repeated,
+// Index là phần tử được chọn; field singular dùng occurrence cuối cùng (bước
+// sub-message singular phải xuất hiện đúng một lần). Message = true khi field
+// là sub-message; field cuối của đường dẫn khi đó cũng phải xuất hiện đúng một
+// lần nếu singular.
+type FieldRef struct {
+	Number   int
+	WireType int
+	Repeated bool
+	Index    int
+	Message  bool
+}
+
+// FieldResolver đổi FieldSpec.Path thành đường dẫn field cho Msg có type URL
+// typeURL.
+type FieldResolver interface {
+	ResolveField(typeURL, path string) ([]FieldRef, error)
+}
+
+// resolveSpec trả về đường dẫn field của spec: từ Path qua resolver, hoặc từ
+// FieldNumbers.
+func resolveSpec(spec FieldSpec, typeURL string, resolver FieldResolver) ([]FieldRef, error) {
+	if spec.Path != "" {
+		if len(spec.FieldNumbers) > 0 || len(spec.Indices) > 0 {
+			return nil, fmt.Errorf("%w: set either Path or FieldNumbers", ErrInvalidSpec)
+		}
+		if resolver == nil {
+			return nil, fmt.Errorf("%w: Path %q needs a FieldResolver", ErrInvalidSpec, spec.Path)
+		}
+		return resolver.ResolveField(typeURL, spec.Path)
+	}
+
+	if len(spec.FieldNumbers) == 0 {
+		return nil, fmt.Errorf("%w: empty field path", ErrInvalidSpec)
+	}
+	if len(spec.Indices) > 0 && len(spec.Indices) != len(spec.FieldNumbers) {
+		return nil, fmt.Errorf("%w: %d indices for %d field numbers", ErrInvalidSpec, len(spec.Indices), len(spec.FieldNumbers))
+	}
+	refs := make([]FieldRef, len(spec.FieldNumbers))
+	for i, number := range spec.FieldNumbers {
+		if number <= 0 || number > maxFieldNumber {
+			return nil, fmt.Errorf("%w: field number %d", ErrInvalidSpec, number)
+		}
+		refs[i] = FieldRef{Number: number, WireType: anyWireType}
+		if len(spec.Indices) > 0 && spec.Indices[i] >= 0 {
+			refs[i].Repeated = true
+			refs[i].Index = spec.Indices[i]
+		}
+	}
+	return refs, nil
+}
+
+// RegistryResolver resolve Path theo descriptor gogoproto của các Msg đã đăng
+// ký trong InterfaceRegistry (vd. ProtoCodec.InterfaceRegistry()).
+type RegistryResolver struct {
+	Registry codectypes.InterfaceRegistry
+}
+
+// NewRegistryResolver trả về resolver dùng registry.
+func NewRegistryResolver(registry codectypes.InterfaceRegistry) *RegistryResolver {
+	return &RegistryResolver{Registry: registry}
+}
+
+// ResolveField tra từng tên trong path (phân tách bằng dấu chấm, tên field
+// trong file .proto) trên descriptor của Msg. Mọi bước trừ bước cuối phải là
+// field kiểu message. Field repeated phải chọn phần tử bằng chỉ số, vd.
+// "amount[1].denom"; field singular không được có chỉ số.
+func (r *RegistryResolver) ResolveField(typeURL, path string) ([]FieldRef, error) {
+	msg, err := r.Registry.Resolve(typeURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMsgType, typeURL)
+	}
+	name := proto.MessageName(msg)
+	desc, err := r.Registry.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: no descriptor for %s: %w", ErrUnknownMsgType, name, err)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a message", ErrUnknownMsgType, name)
+	}
+
+	names := strings.Split(path, ".")
+	refs := make([]FieldRef, len(names))
+	for i, name := range names {
+		fieldName, index, hasIndex, ok := splitIndex(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed path %q", ErrInvalidSpec, path)
+		}
+		fd := msgDesc.Fields().ByName(protoreflect.Name(fieldName))
+		if fd == nil {
+			return nil, fmt.Errorf("%w: %s has no field %q", ErrFieldNotFound, msgDesc.FullName(), fieldName)
+		}
+		switch {
+		case fd.IsList() && !hasIndex:
+			return nil, fmt.Errorf("%w: %s.%s is repeated, select an element with [i]", ErrInvalidSpec, msgDesc.FullName(), fieldName)
+		case !fd.IsList() && hasIndex:
+			return nil, fmt.Errorf("%w: %s.%s is not repeated", ErrInvalidSpec, msgDesc.FullName(), fieldName)
+		}
+		refs[i] = FieldRef{
+			Number:   int(fd.Number()),
+			WireType: wireTypeOf(fd),
+			Repeated: fd.IsList(),
+			Index:    index,
+			Message:  fd.Kind() == protoreflect.MessageKind && !fd.IsMap(),
+		}
+
+		if i < len(names)-1 {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsMap() {
+				return nil, fmt.Errorf("%w: %s.%s is not a sub-message", ErrWireType, msgDesc.FullName(), fieldName)
+			}
+			msgDesc = fd.Message()
+		}
+	}
+	return refs, nil
+}
+
+// splitIndex tách "name[i]" thành name và i; ok = false khi name rỗng hoặc
+// chỉ số không hợp lệ.
+func splitIndex(s string) (name string, index int, hasIndex, ok bool) {
+	open := strings.IndexByte(s, '[')
+	if open < 0 {
+		return s, 0, false, s != ""
+	}
+	if open == 0 || !strings.HasSuffix(s, "]") {
+		return "", 0, false, false
+	}
+	index, err := strconv.Atoi(s[open+1 : len(s)-1])
+	if err != nil || index < 0 {
+		return "", 0, false, false
+	}
+	return s[:open], index, true, true
+}
+
+// wireTypeOf trả về wire type mà field fd được encode.
+func wireTypeOf(fd protoreflect.FieldDescriptor) int {
+	if fd.IsPacked() {
+		return wireBytes
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind, protoreflect.EnumKind,
+		protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind:
+		return wireVarint
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind:
+		return wireFixed32
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind:
+		return wireFixed64
+	default:
+		// String, bytes, message.
+		return wireBytes
+	}
+}
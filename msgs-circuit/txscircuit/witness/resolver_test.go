@@ -0,0 +1,161 @@
+package witness
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cosmossdk.io/math"
+	banktypes "cosmossdk.io/x/bank/types"
+	stakingtypes "cosmossdk.io/x/staking/types"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/std"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func newTestResolver() *RegistryResolver {
+	registry := codectypes.NewInterfaceRegistry()
+	std.RegisterInterfaces(registry)
+	banktypes.RegisterInterfaces(registry)
+	stakingtypes.RegisterInterfaces(registry)
+	return NewRegistryResolver(registry)
+}
+
+func TestRegistryResolver(t *testing.T) {
+	resolver := newTestResolver()
+
+	tests := []struct {
+		typeURL string
+		path    string
+		want    []FieldRef
+		err     error
+	}{
+		{sendTypeURL, "amount[0]", []FieldRef{{Number: 3, WireType: wireBytes, Repeated: true, Message: true}}, nil},
+		{sendTypeURL, "amount[1].denom", []FieldRef{{Number: 3, WireType: wireBytes, Repeated: true, Index: 1, Message: true}, {Number: 1, WireType: wireBytes}}, nil},
+		{sendTypeURL, "to_address", []FieldRef{{Number: 2, WireType: wireBytes}}, nil},
+		{delegateTypeURL, "amount", []FieldRef{{Number: 3, WireType: wireBytes, Message: true}}, nil},
+		{delegateTypeURL, "amount.amount", []FieldRef{{Number: 3, WireType: wireBytes, Message: true}, {Number: 2, WireType: wireBytes}}, nil},
+		{sendTypeURL, "amount[0].nope", nil, ErrFieldNotFound},
+		{sendTypeURL, "validator_address", nil, ErrFieldNotFound},
+		{sendTypeURL, "from_address.denom", nil, ErrWireType},
+		{sendTypeURL, "amount[0].", nil, ErrInvalidSpec},
+		{sendTypeURL, "amount.denom", nil, ErrInvalidSpec},
+		{sendTypeURL, "to_address[0]", nil, ErrInvalidSpec},
+		{sendTypeURL, "amount[-1]", nil, ErrInvalidSpec},
+		{"/cosmwasm.wasm.v1.MsgExecuteContract", "sender", nil, ErrUnknownMsgType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typeURL+"/"+tt.path, func(t *testing.T) {
+			got, err := resolver.ResolveField(tt.typeURL, tt.path)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("refs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildAssignmentWithResolver chọn field theo tên trên tx encode bằng
+// gogoproto thật.
+func TestBuildAssignmentWithResolver(t *testing.T) {
+	sendAny, err := codectypes.NewAnyWithValue(&banktypes.MsgSend{
+		FromAddress: testFromAddr,
+		ToAddress:   testToAddr,
+		Amount:      sdk.NewCoins(sdk.NewCoin("uatom", math.NewInt(4242))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delegateAny, err := codectypes.NewAnyWithValue(&stakingtypes.MsgDelegate{
+		DelegatorAddress: testFromAddr,
+		ValidatorAddress: testValAddr,
+		Amount:           sdk.NewCoin("stake", math.NewInt(777)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := buildTx("", anyBytes(sendAny.TypeUrl, sendAny.Value), anyBytes(delegateAny.TypeUrl, delegateAny.Value))
+	resolver := newTestResolver()
+
+	assignment, configs, err := BuildAssignmentWithResolver(tx, []FieldSpec{
+		{MsgIndex: 0, Path: "amount[0].denom"},
+		{MsgIndex: 1, Path: "validator_address"},
+	}, resolver)
+	if err != nil {
+		t.Fatalf("BuildAssignmentWithResolver: %v", err)
+	}
+	if got := assignmentBytes(assignment.Msgs[1].Field.Value, len(testValAddr)); string(got) != testValAddr {
+		t.Fatalf("validator_address = %q", got)
+	}
+	assertSolved(t, tx, assignment, configs)
+
+	// MsgDelegate.amount là Coin singular: chứng minh cả sub-message thì
+	// circuit đòi nó xuất hiện đúng một lần.
+	assignment, configs, err = BuildAssignmentWithResolver(tx, []FieldSpec{
+		{MsgIndex: 0, Path: "amount[0]"},
+		{MsgIndex: 1, Path: "amount"},
+	}, resolver)
+	if err != nil {
+		t.Fatalf("BuildAssignmentWithResolver: %v", err)
+	}
+	if configs[0].MessageField || !configs[1].MessageField {
+		t.Fatalf("MessageField = %v, %v, want false, true", configs[0].MessageField, configs[1].MessageField)
+	}
+	assertSolved(t, tx, assignment, configs)
+
+	dupAmount := appendBytesField(delegateAny.Value, 3, coinBytes("", "1"))
+	dupTx := buildTx("", anyBytes(sendAny.TypeUrl, sendAny.Value), anyBytes(delegateAny.TypeUrl, dupAmount))
+	_, _, err = BuildAssignmentWithResolver(dupTx, []FieldSpec{{MsgIndex: 0, Path: "amount[0]"}, {MsgIndex: 1, Path: "amount"}}, resolver)
+	if !errors.Is(err, ErrUnsupportedTx) {
+		t.Fatalf("duplicated singular amount: err = %v, want %v", err, ErrUnsupportedTx)
+	}
+
+	_, _, err = BuildAssignment(tx, []FieldSpec{{MsgIndex: 0, Path: "amount[0]"}, {MsgIndex: 1, Path: "amount"}})
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("Path without resolver: err = %v, want %v", err, ErrInvalidSpec)
+	}
+
+	var fieldErr *FieldError
+	_, _, err = BuildAssignmentWithResolver(tx, []FieldSpec{{MsgIndex: 0, Path: "amount[0]"}, {MsgIndex: 1, Path: "shares"}}, resolver)
+	if !errors.Is(err, ErrFieldNotFound) || !errors.As(err, &fieldErr) || fieldErr.MsgIndex != 1 {
+		t.Fatalf("unknown field: err = %v, want %v for msg 1", err, ErrFieldNotFound)
+	}
+}
+
+// TestBuildAssignmentRepeated: mỗi coin của MsgSend.amount nhiều coin đều
+// chứng minh được, kể cả phần tử không phải occurrence cuối cùng.
+func TestBuildAssignmentRepeated(t *testing.T) {
+	sendAny, err := codectypes.NewAnyWithValue(&banktypes.MsgSend{
+		FromAddress: testFromAddr,
+		ToAddress:   testToAddr,
+		Amount:      sdk.NewCoins(sdk.NewCoin("uatom", math.NewInt(4242)), sdk.NewCoin("ustake", math.NewInt(10))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := buildTx("", anyBytes(sendAny.TypeUrl, sendAny.Value))
+	resolver := newTestResolver()
+
+	for k, denom := range []string{"uatom", "ustake"} {
+		path := fmt.Sprintf("amount[%d].denom", k)
+		assignment, configs, err := BuildAssignmentWithResolver(tx, []FieldSpec{{MsgIndex: 0, Path: path}}, resolver)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if got := assignmentBytes(assignment.Msgs[0].Field.Value, len(denom)); string(got) != denom {
+			t.Fatalf("%s = %q, want %q", path, got, denom)
+		}
+		if !reflect.DeepEqual(configs[0].RepeatedPath, []bool{true}) || assignment.Msgs[0].Path[0].Index != k {
+			t.Fatalf("%s: RepeatedPath = %v, Index = %v", path, configs[0].RepeatedPath, assignment.Msgs[0].Path[0].Index)
+		}
+		assertSolved(t, tx, assignment, configs)
+	}
+
+	_, _, err = BuildAssignmentWithResolver(tx, []FieldSpec{{MsgIndex: 0, Path: "amount[2]"}}, resolver)
+	if !errors.Is(err, ErrFieldNotFound) {
+		t.Fatalf("amount[2]: err = %v, want %v", err, ErrFieldNotFound)
+	}
+}
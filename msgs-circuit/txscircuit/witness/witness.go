@@ -0,0 +1,430 @@
+// Package witness dựng assignment cho txscircuit.TxsFieldCircuit từ tx bytes:
+// parse TxRaw/TxBody đúng chuẩn protobuf, chọn occurrence cuối cùng của mỗi
+// field singular (giá trị decoder thực sự dùng), occurrence duy nhất của mỗi
+// sub-message singular trên đường dẫn, hoặc phần tử được chỉ định của field
+// repeated, và tính các offset mà circuit cần.
+package witness
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+)
+
+var (
+	// ErrMalformedTx: tx không phải encoding protobuf hợp lệ của TxRaw.
+	ErrMalformedTx = errors.New("witness: malformed tx")
+	// ErrUnsupportedTx: tx hợp lệ nhưng có layout TxsFieldCircuit không chứng
+	// minh được (vd. key nhiều byte, extension options, field cố định 32/64 bit).
+	ErrUnsupportedTx = errors.New("witness: tx layout not supported by circuit")
+	// ErrInvalidSpec: FieldSpec không hợp lệ hoặc không phủ đúng mỗi message
+	// một lần.
+	ErrInvalidSpec = errors.New("witness: invalid field spec")
+	// ErrFieldNotFound: message không chứa field được chọn.
+	ErrFieldNotFound = errors.New("witness: field not found")
+	// ErrWireType: field trên đường dẫn không phải sub-message, hoặc field được
+	// chọn không phải varint / length-delimited.
+	ErrWireType = errors.New("witness: unexpected wire type")
+	// ErrUnknownMsgType: FieldResolver không biết type URL của message.
+	ErrUnknownMsgType = errors.New("witness: unknown message type")
+	// ErrValueTooLong: value dài hơn FieldSpec.MaxValueLen.
+	ErrValueTooLong = errors.New("witness: field value longer than MaxValueLen")
+	// ErrExceedsShape: tx dài hơn Options.MaxTxLen, hoặc type URL / số field
+	// vượt sức chứa Options đặt cho shape dùng chung.
+	ErrExceedsShape = errors.New("witness: tx exceeds circuit shape")
+)
+
+// ParseError gắn vị trí byte trong tx vào lỗi parse.
+type ParseError struct {
+	Offset int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError cho biết FieldSpec nào gây lỗi.
+type FieldError struct {
+	MsgIndex     int
+	FieldNumbers []int
+	Path         string
+	Err          error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("msg %d field %q: %v", e.MsgIndex, e.Path, e.Err)
+	}
+	return fmt.Sprintf("msg %d field %v: %v", e.MsgIndex, e.FieldNumbers, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldSpec chọn field cần chứng minh trong message thứ MsgIndex của TxBody.
+type FieldSpec struct {
+	MsgIndex int
+	// FieldNumbers là đường dẫn field number từ message value tới field, vd.
+	// MsgSend.amount là {3}, MsgSend.amount.denom là {3, 1}.
+	FieldNumbers []int
+	// Indices đi kèm FieldNumbers: Indices[d] >= 0 coi bước d là field
+	// repeated và chọn phần tử thứ Indices[d], < 0 là field singular. Rỗng:
+	// mọi bước là singular.
+	Indices []int
+	// Path chọn field theo tên thay cho FieldNumbers, vd. "to_address" hoặc
+	// "amount[0].denom"; được FieldResolver tra theo type URL của message.
+	Path string
+	// MaxValueLen > 0 pad value tới MaxValueLen (MsgConfig.MaxFieldValueLen)
+	// để các tx có value dài ngắn khác nhau dùng chung shape circuit.
+	MaxValueLen int
+}
+
+// Options cấu hình BuildAssignmentWithOptions. Giá trị zero cho assignment
+// của circuit cố định len(txBytes), giống BuildAssignment.
+type Options struct {
+	// Resolver đổi FieldSpec.Path thành field number theo type URL; nil chỉ
+	// nhận spec dùng FieldNumbers.
+	Resolver FieldResolver
+	// MaxTxLen > 0 dựng assignment cho
+	// txscircuit.NewTxsFieldCircuitMaxLen(MaxTxLen, configs) và để
+	// MsgValueLen = 0, nên độ dài tx và message value không còn nằm trong
+	// shape. Cùng với MaxTypeURLLen và FieldSpec.MaxValueLen, các tx khác độ
+	// dài dùng chung một ShapeKey (một proving key).
+	MaxTxLen int
+	// MaxTypeURLLen > 0 là MsgConfig.MaxTypeURLLen của mọi message thay cho
+	// độ dài type URL thật.
+	MaxTypeURLLen int
+	// MaxFields > 0 là MsgConfig.MaxFields của mọi message thay cho số field
+	// thật khi số đó vượt DefaultMaxMsgFields.
+	MaxFields int
+}
+
+// selection là field đã chọn trong một message, dạng mà MsgAssertion cần.
+type selection struct {
+	typeURL     []byte
+	path        []pathStep
+	key         byte
+	fieldIndex  int
+	fieldOffset int
+	value       []byte
+	varint      uint64
+	bodyOffset  int
+}
+
+type pathStep struct {
+	key    byte
+	index  int
+	offset int
+}
+
+// BuildAssignment parse txBytes và trả về assignment của TxsFieldCircuit cùng
+// các MsgConfig tương ứng; circuit để compile là
+// txscircuit.NewTxsFieldCircuit(len(txBytes), configs).
+//
+// specs phải phủ mỗi message trong TxBody đúng một lần (circuit chứng minh
+// danh sách message là đầy đủ). Với field singular bị lặp lại, field được chọn
+// là occurrence cuối cùng; riêng sub-message singular bị lặp lại (trên đường
+// dẫn, hoặc là field cuối khi resolver biết kiểu của nó) thì trả về
+// ErrUnsupportedTx vì decoder merge chúng. Spec chọn field bằng Path cần
+// BuildAssignmentWithResolver.
+func BuildAssignment(txBytes []byte, specs []FieldSpec) (*txscircuit.TxsFieldCircuit, []txscircuit.MsgConfig, error) {
+	return BuildAssignmentWithResolver(txBytes, specs, nil)
+}
+
+// BuildAssignmentWithResolver giống BuildAssignment nhưng dùng resolver để đổi
+// FieldSpec.Path thành field number theo type URL của từng message.
+func BuildAssignmentWithResolver(txBytes []byte, specs []FieldSpec, resolver FieldResolver) (*txscircuit.TxsFieldCircuit, []txscircuit.MsgConfig, error) {
+	return BuildAssignmentWithOptions(txBytes, specs, Options{Resolver: resolver})
+}
+
+// BuildAssignmentWithOptions giống BuildAssignment với các tùy chọn trong
+// opts. Khi opts.MaxTxLen > 0, circuit để compile là
+// txscircuit.NewTxsFieldCircuitMaxLen(opts.MaxTxLen, configs); ShapeKey của
+// assignment trả về là ShapeKey của circuit đó.
+func BuildAssignmentWithOptions(txBytes []byte, specs []FieldSpec, opts Options) (*txscircuit.TxsFieldCircuit, []txscircuit.MsgConfig, error) {
+	if opts.MaxTxLen > 0 && len(txBytes) > opts.MaxTxLen {
+		return nil, nil, fmt.Errorf("%w: tx is %d bytes, MaxTxLen is %d", ErrExceedsShape, len(txBytes), opts.MaxTxLen)
+	}
+	msgs, err := parseTx(txBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	ordered, err := orderSpecs(specs, len(msgs))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configs := make([]txscircuit.MsgConfig, len(msgs))
+	selections := make([]selection, len(msgs))
+	for i, spec := range ordered {
+		sel, cfg, err := selectField(txBytes, msgs[i], spec, opts)
+		if err != nil {
+			return nil, nil, &FieldError{MsgIndex: spec.MsgIndex, FieldNumbers: spec.FieldNumbers, Path: spec.Path, Err: err}
+		}
+		selections[i], configs[i] = sel, cfg
+	}
+
+	var assignment *txscircuit.TxsFieldCircuit
+	if opts.MaxTxLen > 0 {
+		assignment = txscircuit.NewTxsFieldCircuitMaxLen(opts.MaxTxLen, configs)
+	} else {
+		assignment = txscircuit.NewTxsFieldCircuit(len(txBytes), configs)
+	}
+	copyBytes(assignment.PublicTxBytes, txBytes)
+	assignment.TxLen = len(txBytes)
+	for i, sel := range selections {
+		msg := &assignment.Msgs[i]
+		copyBytes(msg.TypeURL, sel.typeURL)
+		for d, step := range sel.path {
+			msg.Path[d].Key = step.key
+			msg.Path[d].Index = step.index
+			msg.Path[d].Offset = step.offset
+		}
+		msg.Field.Key = sel.key
+		msg.Field.Index = sel.fieldIndex
+		copyBytes(msg.Field.Value, sel.value)
+		msg.Field.Len = len(sel.value)
+		msg.Field.Varint = sel.varint
+		msg.FieldOffset = sel.fieldOffset
+		msg.BodyOffset = sel.bodyOffset
+	}
+	return assignment, configs, nil
+}
+
+// parseTx kiểm tra layout TxRaw/TxBody mà circuit giả định và trả về các field
+// messages (field 1) của TxBody theo thứ tự.
+func parseTx(tx []byte) ([]field, error) {
+	if len(tx) == 0 {
+		return nil, &ParseError{Offset: 0, Err: fmt.Errorf("%w: empty tx", ErrMalformedTx)}
+	}
+	fields, err := parseFields(tx, 0, len(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	// TxRaw: body_bytes (1), auth_info_bytes (2), signatures (3).
+	for i, f := range fields {
+		if f.Number > 3 {
+			return nil, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: unknown TxRaw field %d", ErrUnsupportedTx, f.Number)}
+		}
+		if f.WireType != wireBytes {
+			return nil, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: TxRaw field %d has wire type %d", ErrMalformedTx, f.Number, f.WireType)}
+		}
+		if f.Number == 1 && i > 0 {
+			return nil, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: body_bytes must be the first and only TxRaw field 1", ErrUnsupportedTx)}
+		}
+		// Decoder của SDK từ chối TxRaw có tag giảm dần (ADR-027).
+		if i > 0 && f.Number < fields[i-1].Number {
+			return nil, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: TxRaw field %d after field %d", ErrMalformedTx, f.Number, fields[i-1].Number)}
+		}
+	}
+	if fields[0].Number != 1 {
+		return nil, &ParseError{Offset: 0, Err: fmt.Errorf("%w: tx does not start with body_bytes", ErrUnsupportedTx)}
+	}
+	if err := checkTokenizable(fields); err != nil {
+		return nil, err
+	}
+	if len(fields) > txscircuit.MaxTxRawFields {
+		return nil, fmt.Errorf("%w: %d TxRaw fields, circuit reads at most %d", ErrUnsupportedTx, len(fields), txscircuit.MaxTxRawFields)
+	}
+
+	body := fields[0]
+	bodyFields, err := parseFields(tx, body.PayloadStart, body.End)
+	if err != nil {
+		return nil, err
+	}
+
+	// Circuit đọc các message liên tiếp từ đầu body, phần còn lại phải
+	// tokenize được và không chứa message nào.
+	numMsgs := 0
+	for numMsgs < len(bodyFields) && bodyFields[numMsgs].Number == 1 {
+		if bodyFields[numMsgs].WireType != wireBytes {
+			return nil, &ParseError{Offset: bodyFields[numMsgs].Start, Err: fmt.Errorf("%w: TxBody.messages has wire type %d", ErrMalformedTx, bodyFields[numMsgs].WireType)}
+		}
+		numMsgs++
+	}
+	tail := bodyFields[numMsgs:]
+	for _, f := range tail {
+		if f.Number == 1 {
+			return nil, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: messages interleaved with other TxBody fields", ErrUnsupportedTx)}
+		}
+	}
+	if err := checkTokenizable(tail); err != nil {
+		return nil, err
+	}
+	if len(tail) > txscircuit.DefaultMaxTailFields {
+		return nil, fmt.Errorf("%w: %d TxBody fields after messages, circuit reads at most %d", ErrUnsupportedTx, len(tail), txscircuit.DefaultMaxTailFields)
+	}
+	return bodyFields[:numMsgs], nil
+}
+
+// orderSpecs sắp specs theo MsgIndex và kiểm tra mỗi message có đúng một spec.
+func orderSpecs(specs []FieldSpec, numMsgs int) ([]FieldSpec, error) {
+	if len(specs) != numMsgs {
+		return nil, fmt.Errorf("%w: tx has %d messages, got %d specs", ErrInvalidSpec, numMsgs, len(specs))
+	}
+	ordered := append([]FieldSpec(nil), specs...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].MsgIndex < ordered[j].MsgIndex })
+	for i, spec := range ordered {
+		if spec.MsgIndex != i {
+			return nil, fmt.Errorf("%w: specs must cover messages 0..%d exactly once", ErrInvalidSpec, numMsgs-1)
+		}
+	}
+	return ordered, nil
+}
+
+// selectField đi theo đường dẫn field của spec trong message msg (một Any trong
+// TxBody) và trả về field được chọn cùng MsgConfig tương ứng.
+func selectField(tx []byte, msg field, spec FieldSpec, opts Options) (selection, txscircuit.MsgConfig, error) {
+	// Any: type_url (1) rồi value (2), không có gì khác.
+	anyFields, err := parseFields(tx, msg.PayloadStart, msg.End)
+	if err != nil {
+		return selection{}, txscircuit.MsgConfig{}, err
+	}
+	if len(anyFields) != 2 ||
+		anyFields[0].Number != 1 || anyFields[0].WireType != wireBytes || anyFields[0].KeyLen != 1 ||
+		anyFields[1].Number != 2 || anyFields[1].WireType != wireBytes || anyFields[1].KeyLen != 1 {
+		return selection{}, txscircuit.MsgConfig{}, &ParseError{Offset: msg.Start, Err: fmt.Errorf("%w: message is not an Any{type_url, value}", ErrUnsupportedTx)}
+	}
+	typeURL := anyFields[0].payload(tx)
+	value := anyFields[1]
+
+	refs, err := resolveSpec(spec, string(typeURL), opts.Resolver)
+	if err != nil {
+		return selection{}, txscircuit.MsgConfig{}, err
+	}
+
+	sel := selection{
+		typeURL:    typeURL,
+		bodyOffset: msg.Start,
+	}
+	maxFields := 0
+	var repeatedPath []bool
+	repeatedField := false
+	messageField := false
+	regionStart, regionEnd := value.PayloadStart, value.End
+	for d, ref := range refs {
+		number := ref.Number
+		fields, err := parseFields(tx, regionStart, regionEnd)
+		if err != nil && d > 0 {
+			// Payload length-delimited không parse được thành message (vd. string).
+			return selection{}, txscircuit.MsgConfig{}, fmt.Errorf("%w: path field %d is not a sub-message: %w", ErrWireType, refs[d-1].Number, err)
+		}
+		if err != nil {
+			return selection{}, txscircuit.MsgConfig{}, err
+		}
+		if err := checkTokenizable(fields); err != nil {
+			return selection{}, txscircuit.MsgConfig{}, err
+		}
+		maxFields = max(maxFields, len(fields))
+
+		var f field
+		if ref.Repeated {
+			f, err = nthOccurrence(fields, number, ref.Index)
+		} else {
+			f, err = lastOccurrence(fields, number)
+		}
+		if err != nil {
+			return selection{}, txscircuit.MsgConfig{}, err
+		}
+		if ref.WireType != anyWireType && f.WireType != ref.WireType {
+			return selection{}, txscircuit.MsgConfig{}, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: field %d has wire type %d, descriptor says %d", ErrWireType, number, f.WireType, ref.WireType)}
+		}
+		key := tx[f.Start]
+		offset := f.Start - regionStart
+
+		if d < len(refs)-1 {
+			if f.WireType != wireBytes {
+				return selection{}, txscircuit.MsgConfig{}, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: path field %d is not a sub-message", ErrWireType, number)}
+			}
+			// Sub-message singular lặp lại được decoder merge: circuit chỉ chứng
+			// minh được bước singular xuất hiện đúng một lần.
+			if n := countOccurrences(fields, number); !ref.Repeated && n > 1 {
+				return selection{}, txscircuit.MsgConfig{}, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: singular sub-message field %d occurs %d times", ErrUnsupportedTx, number, n)}
+			}
+			sel.path = append(sel.path, pathStep{key: key, index: ref.Index, offset: offset})
+			repeatedPath = append(repeatedPath, ref.Repeated)
+			regionStart, regionEnd = f.PayloadStart, f.End
+			continue
+		}
+
+		// Field cuối là sub-message singular: cũng bị merge như bước trên Path.
+		if n := countOccurrences(fields, number); ref.Message && !ref.Repeated && n > 1 {
+			return selection{}, txscircuit.MsgConfig{}, &ParseError{Offset: f.Start, Err: fmt.Errorf("%w: singular sub-message field %d occurs %d times", ErrUnsupportedTx, number, n)}
+		}
+		sel.key = key
+		sel.fieldIndex = ref.Index
+		sel.fieldOffset = offset
+		repeatedField = ref.Repeated
+		messageField = ref.Message && !ref.Repeated
+		if f.WireType == wireVarint {
+			sel.varint = f.Varint
+		} else {
+			sel.value = f.payload(tx)
+		}
+	}
+
+	cfg := txscircuit.MsgConfig{
+		MsgValueLen:   int(value.Varint),
+		PathDepth:     len(sel.path),
+		MaxTypeURLLen: len(typeURL),
+		VarintField:   isVarintKey(sel.key),
+		RepeatedField: repeatedField,
+		MessageField:  messageField,
+	}
+	if slices.Contains(repeatedPath, true) {
+		cfg.RepeatedPath = repeatedPath
+	}
+	if opts.MaxTxLen > 0 {
+		cfg.MsgValueLen = 0
+	}
+	if opts.MaxTypeURLLen > 0 {
+		if len(typeURL) > opts.MaxTypeURLLen {
+			return selection{}, txscircuit.MsgConfig{}, fmt.Errorf("%w: type URL is %d bytes, MaxTypeURLLen is %d", ErrExceedsShape, len(typeURL), opts.MaxTypeURLLen)
+		}
+		cfg.MaxTypeURLLen = opts.MaxTypeURLLen
+	}
+	switch {
+	case opts.MaxFields > 0 && maxFields > opts.MaxFields:
+		return selection{}, txscircuit.MsgConfig{}, fmt.Errorf("%w: message has %d fields, MaxFields is %d", ErrExceedsShape, maxFields, opts.MaxFields)
+	case opts.MaxFields > 0:
+		cfg.MaxFields = opts.MaxFields
+	case maxFields > txscircuit.DefaultMaxMsgFields:
+		cfg.MaxFields = maxFields
+	}
+	if !cfg.VarintField {
+		switch {
+		case spec.MaxValueLen == 0:
+			cfg.FieldValueLen = len(sel.value)
+		case len(sel.value) > spec.MaxValueLen:
+			return selection{}, txscircuit.MsgConfig{}, fmt.Errorf("%w: %d > %d", ErrValueTooLong, len(sel.value), spec.MaxValueLen)
+		default:
+			cfg.MaxFieldValueLen = spec.MaxValueLen
+		}
+	}
+	return sel, cfg, nil
+}
+
+func isVarintKey(key byte) bool {
+	return key&7 == wireVarint
+}
+
+func copyBytes(dst []frontend.Variable, data []byte) {
+	for i := range dst {
+		dst[i] = 0
+		if i < len(data) {
+			dst[i] = data[i]
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package witness
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	txscircuit "github.com/DongLieu/msg-circuit/txscircuit"
+)
+
+const (
+	testFromAddr = "cosmos18qqv7rruzf82htyqzn7g3f93722exlmckte82g"
+	testToAddr   = "cosmos17d2ar63s0qyvnd2z9esny4yy0vnw0exxctc2ny"
+	testValAddr  = "cosmosvaloper1l2rsakp388kuv9k8qzq6lrm9taddae7fpx59wm"
+
+	sendTypeURL     = "/cosmos.bank.v1beta1.MsgSend"
+	delegateTypeURL = "/cosmos.staking.v1beta1.MsgDelegate"
+)
+
+func TestBuildAssignment(t *testing.T) {
+	sendValue := msgSendValue(coinBytes("uatom", "4242"))
+	delegateValue := msgDelegateValue(coinBytes("stake", "777"))
+	tx := buildTx("memo", anyBytes(sendTypeURL, sendValue), anyBytes(delegateTypeURL, delegateValue))
+
+	tests := []struct {
+		name  string
+		specs []FieldSpec
+		want  [][]byte
+	}{
+		{
+			name:  "direct fields",
+			specs: []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{3}}, {MsgIndex: 1, FieldNumbers: []int{1}}},
+			want:  [][]byte{coinBytes("uatom", "4242"), []byte(testFromAddr)},
+		},
+		{
+			name:  "nested and unordered specs",
+			specs: []FieldSpec{{MsgIndex: 1, FieldNumbers: []int{3, 2}}, {MsgIndex: 0, FieldNumbers: []int{3, 1}}},
+			want:  [][]byte{[]byte("uatom"), []byte("777")},
+		},
+		{
+			name:  "padded value",
+			specs: []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{2}, MaxValueLen: 64}, {MsgIndex: 1, FieldNumbers: []int{2}, MaxValueLen: 64}},
+			want:  [][]byte{[]byte(testToAddr), []byte(testValAddr)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment, configs := mustBuild(t, tx, tt.specs)
+			for i, want := range tt.want {
+				if got := assignment.Msgs[i].Field.Len; got != len(want) {
+					t.Errorf("msg %d: Len = %v, want %d", i, got, len(want))
+				}
+				if got := assignmentBytes(assignment.Msgs[i].Field.Value, len(want)); !bytes.Equal(got, want) {
+					t.Errorf("msg %d: value = %q, want %q", i, got, want)
+				}
+			}
+			assertSolved(t, tx, assignment, configs)
+		})
+	}
+}
+
+// TestBuildAssignmentLastOccurrence: với field singular bị lặp lại, builder
+// phải chọn occurrence cuối cùng, giá trị decoder dùng; Indices chọn phần tử
+// khi field là repeated.
+func TestBuildAssignmentLastOccurrence(t *testing.T) {
+	hidden := coinBytes("uatom", "10000")
+	public := coinBytes("uatom", "4242")
+	var value []byte
+	value = appendBytesField(value, 1, []byte(testFromAddr))
+	value = appendBytesField(value, 2, []byte(testToAddr))
+	value = appendBytesField(value, 3, hidden)
+	value = appendBytesField(value, 3, public)
+	tx := buildTx("", anyBytes(sendTypeURL, value))
+
+	assignment, configs := mustBuild(t, tx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{3}}})
+	if got := assignmentBytes(assignment.Msgs[0].Field.Value, len(public)); !bytes.Equal(got, public) {
+		t.Fatalf("value = %x, want last occurrence %x", got, public)
+	}
+	assertSolved(t, tx, assignment, configs)
+	// Coi amount là repeated: cả hai phần tử đều chứng minh được.
+	for k, want := range [][]byte{hidden, public} {
+		assignment, configs = mustBuild(t, tx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{3}, Indices: []int{k}}})
+		if got := assignmentBytes(assignment.Msgs[0].Field.Value, len(want)); !bytes.Equal(got, want) || !configs[0].RepeatedField {
+			t.Fatalf("amount[%d] = %x (repeated %v), want %x", k, got, configs[0].RepeatedField, want)
+		}
+		assertSolved(t, tx, assignment, configs)
+	}
+}
+
+func TestBuildAssignmentVarintField(t *testing.T) {
+	var value []byte
+	value = appendBytesField(value, 1, []byte(testFromAddr))
+	value = appendVarintField(value, 2, 300)
+	value = appendVarintField(value, 2, 1<<40)
+	tx := buildTx("", anyBytes("/test.MsgVarint", value))
+
+	assignment, configs := mustBuild(t, tx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{2}}})
+	if !configs[0].VarintField {
+		t.Fatal("varint field not detected")
+	}
+	if got := assignment.Msgs[0].Field.Varint; got != uint64(1<<40) {
+		t.Fatalf("Varint = %v, want %d", got, uint64(1<<40))
+	}
+	assertSolved(t, tx, assignment, configs)
+}
+
+// TestBuildAssignmentMaxShape: với Options.MaxTxLen, hai tx khác độ dài (khác
+// memo, amount, type URL) cho cùng ShapeKey và cùng được một circuit chấp nhận.
+func TestBuildAssignmentMaxShape(t *testing.T) {
+	const maxTxLen = 512
+	opts := Options{MaxTxLen: maxTxLen, MaxTypeURLLen: 64}
+	specs := []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{3, 2}, MaxValueLen: 16}}
+	txs := [][]byte{
+		buildTx("", anyBytes(sendTypeURL, msgSendValue(coinBytes("uatom", "1")))),
+		buildTx("a longer memo", anyBytes("/cosmos.bank.v1beta1.MsgSendLonger", msgSendValue(coinBytes("uatom", "123456789")))),
+	}
+	if len(txs[0]) == len(txs[1]) {
+		t.Fatal("test txs must differ in length")
+	}
+
+	var circuit *txscircuit.TxsFieldCircuit
+	var shapeKey string
+	for i, tx := range txs {
+		assignment, configs, err := BuildAssignmentWithOptions(tx, specs, opts)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		if configs[0].MsgValueLen != 0 || configs[0].MaxTypeURLLen != opts.MaxTypeURLLen {
+			t.Fatalf("tx %d: config %+v pins tx-specific lengths", i, configs[0])
+		}
+		if i == 0 {
+			circuit = txscircuit.NewTxsFieldCircuitMaxLen(maxTxLen, configs)
+			shapeKey = circuit.ShapeKey()
+		}
+		if got := assignment.ShapeKey(); got != shapeKey {
+			t.Fatalf("tx %d: ShapeKey = %s, want %s", i, got, shapeKey)
+		}
+		if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("tx %d: shared circuit rejected assignment: %v", i, err)
+		}
+	}
+
+	for name, opts := range map[string]Options{
+		"tx too long":       {MaxTxLen: len(txs[1]) - 1},
+		"type URL too long": {MaxTxLen: maxTxLen, MaxTypeURLLen: len(sendTypeURL) - 1},
+		"too many fields":   {MaxTxLen: maxTxLen, MaxFields: 2},
+	} {
+		if _, _, err := BuildAssignmentWithOptions(txs[1], specs, opts); !errors.Is(err, ErrExceedsShape) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrExceedsShape)
+		}
+	}
+}
+
+func TestBuildAssignmentErrors(t *testing.T) {
+	sendValue := msgSendValue(coinBytes("uatom", "4242"))
+	validTx := buildTx("", anyBytes(sendTypeURL, sendValue))
+	amountSpec := []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{3}}}
+
+	// Độ dài body encode 2 byte với byte cuối 0: non-canonical.
+	nonCanonical := append([]byte{0x0a, validTx[1] | 0x80, 0x00}, validTx[2:]...)
+
+	var memoFirst []byte
+	memoFirst = appendBytesField(memoFirst, 2, []byte("memo"))
+	memoFirst = appendBytesField(memoFirst, 1, anyBytes(sendTypeURL, sendValue))
+
+	var fixed64 []byte
+	fixed64 = appendBytesField(fixed64, 1, []byte(testFromAddr))
+	fixed64 = append(fixed64, 0x11, 1, 2, 3, 4, 5, 6, 7, 8)
+
+	// MsgDelegate.amount (Coin singular) lặp lại: decoder merge hai Coin.
+	dupAmount := appendBytesField(msgDelegateValue(coinBytes("stake", "10000")), 3, coinBytes("stake", "777"))
+
+	// Vượt MaxTxRawFields: quá nhiều signatures.
+	manySigs := validTx
+	for range txscircuit.MaxTxRawFields {
+		manySigs = appendBytesField(manySigs, 3, bytes.Repeat([]byte{0x42}, 64))
+	}
+
+	tests := []struct {
+		name  string
+		tx    []byte
+		specs []FieldSpec
+		want  error
+	}{
+		{"empty tx", nil, amountSpec, ErrMalformedTx},
+		{"truncated", validTx[:len(validTx)-10], amountSpec, ErrMalformedTx},
+		{"non-canonical varint", nonCanonical, amountSpec, txscircuit.ErrVarintNonCanonical},
+		{"missing spec", validTx, nil, ErrInvalidSpec},
+		{"duplicate spec", buildTx("", anyBytes(sendTypeURL, sendValue), anyBytes(sendTypeURL, sendValue)), append(amountSpec, amountSpec...), ErrInvalidSpec},
+		{"empty path", validTx, []FieldSpec{{MsgIndex: 0}}, ErrInvalidSpec},
+		{"field not found", validTx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{7}}}, ErrFieldNotFound},
+		{"path through string", validTx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{1, 1}}}, ErrWireType},
+		{"value too long", validTx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{1}, MaxValueLen: 8}}, ErrValueTooLong},
+		{"memo before messages", txRaw(memoFirst), amountSpec, ErrUnsupportedTx},
+		{"fixed64 field", buildTx("", anyBytes("/test.MsgFixed", fixed64)), []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{1}}}, ErrUnsupportedTx},
+		{"too many TxRaw fields", manySigs, amountSpec, ErrUnsupportedTx},
+		{"TxRaw out of order", appendBytesField(validTx, 2, nil), amountSpec, ErrMalformedTx},
+		{"duplicated singular path step", buildTx("", anyBytes(delegateTypeURL, dupAmount)), []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{3, 2}}}, ErrUnsupportedTx},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := BuildAssignment(tt.tx, tt.specs)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	var fieldErr *FieldError
+	_, _, err := BuildAssignment(validTx, []FieldSpec{{MsgIndex: 0, FieldNumbers: []int{7}}})
+	if !errors.As(err, &fieldErr) || fieldErr.MsgIndex != 0 {
+		t.Fatalf("err = %v, want *FieldError for msg 0", err)
+	}
+}
+
+func mustBuild(t *testing.T, tx []byte, specs []FieldSpec) (*txscircuit.TxsFieldCircuit, []txscircuit.MsgConfig) {
+	t.Helper()
+	assignment, configs, err := BuildAssignment(tx, specs)
+	if err != nil {
+		t.Fatalf("BuildAssignment: %v", err)
+	}
+	return assignment, configs
+}
+
+func assertSolved(t *testing.T, tx []byte, assignment *txscircuit.TxsFieldCircuit, configs []txscircuit.MsgConfig) {
+	t.Helper()
+	circuit := txscircuit.NewTxsFieldCircuit(len(tx), configs)
+	if err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatalf("circuit rejected builder assignment: %v", err)
+	}
+}
+
+func assignmentBytes(vars []frontend.Variable, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = vars[i].(byte)
+	}
+	return out
+}
+
+func buildTx(memo string, anyMsgs ...[]byte) []byte {
+	var body []byte
+	for _, msg := range anyMsgs {
+		body = appendBytesField(body, 1, msg)
+	}
+	if memo != "" {
+		body = appendBytesField(body, 2, []byte(memo))
+	}
+	return txRaw(body)
+}
+
+func txRaw(body []byte) []byte {
+	var fee []byte
+	fee = appendBytesField(fee, 1, coinBytes("uatom", "5000"))
+	fee = appendVarintField(fee, 2, 200000)
+	authInfo := appendBytesField(nil, 2, fee)
+
+	var tx []byte
+	tx = appendBytesField(tx, 1, body)
+	tx = appendBytesField(tx, 2, authInfo)
+	return appendBytesField(tx, 3, bytes.Repeat([]byte{0x42}, 64))
+}
+
+func msgSendValue(coin []byte) []byte {
+	var buf []byte
+	buf = appendBytesField(buf, 1, []byte(testFromAddr))
+	buf = appendBytesField(buf, 2, []byte(testToAddr))
+	return appendBytesField(buf, 3, coin)
+}
+
+func msgDelegateValue(coin []byte) []byte {
+	var buf []byte
+	buf = appendBytesField(buf, 1, []byte(testFromAddr))
+	buf = appendBytesField(buf, 2, []byte(testValAddr))
+	return appendBytesField(buf, 3, coin)
+}
+
+func coinBytes(denom, amount string) []byte {
+	buf := appendBytesField(nil, 1, []byte(denom))
+	return appendBytesField(buf, 2, []byte(amount))
+}
+
+func anyBytes(typeURL string, value []byte) []byte {
+	buf := appendBytesField(nil, 1, []byte(typeURL))
+	return appendBytesField(buf, 2, value)
+}
+
+func appendBytesField(buf []byte, fieldNumber int, value []byte) []byte {
+	buf = append(buf, byte(fieldNumber<<3|wireBytes))
+	buf = appendVarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+func appendVarintField(buf []byte, fieldNumber int, value uint64) []byte {
+	buf = append(buf, byte(fieldNumber<<3|wireVarint))
+	return appendVarint(buf, value)
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}